@@ -0,0 +1,154 @@
+package api
+
+import (
+	"context"
+	"sort"
+)
+
+// A ContextSpec declares a context that should exist, along with the
+// environment variables it should hold.
+type ContextSpec struct {
+	Name      string
+	Variables map[string]string
+}
+
+// A ContextApplyResult reports what ApplyContexts changed for a single
+// ContextSpec.
+type ContextApplyResult struct {
+	Name               string
+	ContextID          string
+	Created            bool
+	CreatedVariables   []string
+	UnchangedVariables []string
+}
+
+// GetOrCreateContext returns the named context, creating it first if it does
+// not exist. The returned bool reports whether the context was created.
+func (c *ContextRestClient) GetOrCreateContext(vcs, org, name string) (*Context, bool, error) {
+	return c.getOrCreateContext(context.Background(), vcs, org, name)
+}
+
+func (c *ContextRestClient) getOrCreateContext(ctx context.Context, vcs, org, name string) (*Context, bool, error) {
+	context, err := c.contextByName(ctx, vcs, org, name)
+	if err == nil {
+		return context, false, nil
+	}
+	if !IsNotFoundError(err) {
+		return nil, false, err
+	}
+
+	context, err = c.createContext(ctx, vcs, org, name)
+	if IsConflictError(err) {
+		// Someone else created the context since we looked for it.
+		context, err = c.contextByName(ctx, vcs, org, name)
+		return context, false, err
+	}
+	if err == ErrEmptyResponseBody {
+		// The context was created, but couldn't be looked up.
+		return nil, true, err
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return context, true, nil
+}
+
+// SetEnvironmentVariables creates OR UPDATES each of the given environment
+// variables in the context, in name order. It stops at the first failure.
+func (c *ContextRestClient) SetEnvironmentVariables(contextID string, variables map[string]string) error {
+	for _, name := range sortedKeys(variables) {
+		if err := c.CreateEnvironmentVariable(contextID, name, variables[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyContexts ensures that each of the specified contexts exists and holds
+// the specified environment variables. It is idempotent: contexts and
+// variables which already exist are left untouched, so the values of existing
+// variables are never overwritten. It stops at the first failure, returning
+// the results of the specs applied so far.
+func (c *ContextRestClient) ApplyContexts(vcs, org string, specs []ContextSpec) ([]ContextApplyResult, error) {
+	results := make([]ContextApplyResult, 0, len(specs))
+	for _, spec := range specs {
+		context, created, err := c.GetOrCreateContext(vcs, org, spec.Name)
+		if err != nil {
+			return results, err
+		}
+
+		result := ContextApplyResult{
+			Name:      spec.Name,
+			ContextID: context.ID,
+			Created:   created,
+		}
+
+		existing := map[string]bool{}
+		if !created {
+			envVars, err := c.EnvironmentVariables(context.ID)
+			if err != nil {
+				return results, err
+			}
+			for _, envVar := range *envVars {
+				existing[envVar.Variable] = true
+			}
+		}
+
+		missing := map[string]string{}
+		for name, value := range spec.Variables {
+			if existing[name] {
+				result.UnchangedVariables = append(result.UnchangedVariables, name)
+			} else {
+				missing[name] = value
+				result.CreatedVariables = append(result.CreatedVariables, name)
+			}
+		}
+		sort.Strings(result.UnchangedVariables)
+		sort.Strings(result.CreatedVariables)
+
+		if err := c.SetEnvironmentVariables(context.ID, missing); err != nil {
+			return results, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// PlanEnvironmentVariables compares the desired environment variables of a
+// context with those it holds, as a "plan" step before applying them. Since
+// the API never returns values, they can't be compared: every desired
+// variable which already exists is planned as an update, which overwrites its
+// value. toCreate holds the desired variables which don't exist yet, and
+// toDelete the existing variables which aren't desired. Each is sorted.
+func (c *ContextRestClient) PlanEnvironmentVariables(contextID string, desired map[string]string) (toCreate, toUpdate, toDelete []string, err error) {
+	envVars, err := c.EnvironmentVariables(contextID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	existing := map[string]bool{}
+	for _, envVar := range *envVars {
+		existing[envVar.Variable] = true
+		if _, ok := desired[envVar.Variable]; !ok {
+			toDelete = append(toDelete, envVar.Variable)
+		}
+	}
+	for _, name := range sortedKeys(desired) {
+		if existing[name] {
+			toUpdate = append(toUpdate, name)
+		} else {
+			toCreate = append(toCreate, name)
+		}
+	}
+	sort.Strings(toDelete)
+	return toCreate, toUpdate, toDelete, nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"net/http/httptest"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ApplyContexts", func() {
+	var (
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("creates missing contexts and variables", func() {
+		existingID := fake.addContext("existing", time.Now(), "KEPT")
+
+		results, err := client.ApplyContexts("gh", "test-org", []ContextSpec{
+			{Name: "existing", Variables: map[string]string{"KEPT": "a", "ADDED": "b"}},
+			{Name: "new", Variables: map[string]string{"FOO": "c"}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+
+		Expect(results[0].ContextID).To(Equal(existingID))
+		Expect(results[0].Created).To(BeFalse())
+		Expect(results[0].CreatedVariables).To(Equal([]string{"ADDED"}))
+		Expect(results[0].UnchangedVariables).To(Equal([]string{"KEPT"}))
+
+		Expect(results[1].Created).To(BeTrue())
+		Expect(results[1].CreatedVariables).To(Equal([]string{"FOO"}))
+		Expect(results[1].UnchangedVariables).To(BeEmpty())
+
+		envVars, err := client.EnvironmentVariables(results[1].ContextID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*envVars).To(HaveLen(1))
+		Expect((*envVars)[0].Variable).To(Equal("FOO"))
+	})
+
+	ginkgo.It("changes nothing when applied a second time", func() {
+		specs := []ContextSpec{
+			{Name: "new", Variables: map[string]string{"FOO": "c", "BAR": "d"}},
+		}
+		_, err := client.ApplyContexts("gh", "test-org", specs)
+		Expect(err).ToNot(HaveOccurred())
+
+		before := len(fake.Requests())
+		results, err := client.ApplyContexts("gh", "test-org", specs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results[0].Created).To(BeFalse())
+		Expect(results[0].CreatedVariables).To(BeEmpty())
+		Expect(results[0].UnchangedVariables).To(Equal([]string{"BAR", "FOO"}))
+		for _, request := range fake.Requests()[before:] {
+			Expect(request).To(HavePrefix("GET "))
+		}
+	})
+})
+
+var _ = ginkgo.Describe("PlanEnvironmentVariables", func() {
+	var (
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("categorizes variables to create, update and delete", func() {
+		id := fake.addContext("ctx", time.Now(), "STALE", "KEPT", "OLD", "ALSO_KEPT")
+
+		toCreate, toUpdate, toDelete, err := client.PlanEnvironmentVariables(id, map[string]string{
+			"KEPT":      "a",
+			"NEW":       "b",
+			"ALSO_KEPT": "c",
+			"ANOTHER":   "d",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(toCreate).To(Equal([]string{"ANOTHER", "NEW"}))
+		Expect(toUpdate).To(Equal([]string{"ALSO_KEPT", "KEPT"}))
+		Expect(toDelete).To(Equal([]string{"OLD", "STALE"}))
+	})
+
+	ginkgo.It("plans to create everything in an empty context", func() {
+		id := fake.addContext("ctx", time.Now())
+
+		toCreate, toUpdate, toDelete, err := client.PlanEnvironmentVariables(id, map[string]string{"FOO": "a"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(toCreate).To(Equal([]string{"FOO"}))
+		Expect(toUpdate).To(BeEmpty())
+		Expect(toDelete).To(BeEmpty())
+	})
+
+	ginkgo.It("changes nothing", func() {
+		id := fake.addContext("ctx", time.Now(), "FOO")
+		_, _, _, err := client.PlanEnvironmentVariables(id, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fake.Requests()).To(ConsistOf("GET /api/v2/context/" + id + "/environment-variable"))
+	})
+})
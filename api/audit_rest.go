@@ -0,0 +1,33 @@
+package api
+
+// The operations reported in AuditEvents.
+const (
+	AuditCreateContext             = "create-context"
+	AuditDeleteContext             = "delete-context"
+	AuditCreateEnvironmentVariable = "create-environment-variable"
+	AuditDeleteEnvironmentVariable = "delete-environment-variable"
+)
+
+// An AuditEvent records a successful mutation made through a
+// ContextRestClient. Parameters never include secret values.
+type AuditEvent struct {
+	Operation  string
+	Parameters map[string]string
+	ResourceID string
+}
+
+// WithAuditSink registers a function which is called with an AuditEvent after
+// every successful mutation, such as creating a context or deleting an
+// environment variable. It is called synchronously, so it should return
+// quickly.
+func WithAuditSink(sink func(AuditEvent)) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.auditSink = sink
+	}
+}
+
+func (c *ContextRestClient) audit(event AuditEvent) {
+	if c.auditSink != nil {
+		c.auditSink(event)
+	}
+}
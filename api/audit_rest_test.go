@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WithAuditSink", func() {
+	var (
+		mu     sync.Mutex
+		events []AuditEvent
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		events = nil
+		fake, server, client = newFakeContextServer(WithAuditSink(func(event AuditEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("records each successful mutation", func() {
+		Expect(client.CreateContext("gh", "test-org", "ctx")).To(Succeed())
+		context, err := client.ContextByName("gh", "test-org", "ctx")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.CreateEnvironmentVariable(context.ID, "FOO", "super-secret")).To(Succeed())
+		Expect(client.DeleteEnvironmentVariable(context.ID, "FOO")).To(Succeed())
+		Expect(client.DeleteContext(context.ID)).To(Succeed())
+
+		Expect(events).To(Equal([]AuditEvent{
+			{
+				Operation:  AuditCreateContext,
+				Parameters: map[string]string{"vcs": "gh", "org": "test-org", "name": "ctx"},
+				ResourceID: context.ID,
+			},
+			{
+				Operation:  AuditCreateEnvironmentVariable,
+				Parameters: map[string]string{"context_id": context.ID, "variable": "FOO"},
+				ResourceID: "FOO",
+			},
+			{
+				Operation:  AuditDeleteEnvironmentVariable,
+				Parameters: map[string]string{"context_id": context.ID, "variable": "FOO"},
+				ResourceID: "FOO",
+			},
+			{
+				Operation:  AuditDeleteContext,
+				Parameters: map[string]string{"context_id": context.ID},
+				ResourceID: context.ID,
+			},
+		}))
+		for _, event := range events {
+			for _, value := range event.Parameters {
+				Expect(value).ToNot(ContainSubstring("super-secret"))
+			}
+		}
+	})
+
+	ginkgo.It("records nothing for failed mutations", func() {
+		id := fake.addContext("ctx", time.Now())
+		fake.fail = func(*http.Request) int { return http.StatusInternalServerError }
+
+		Expect(client.CreateContext("gh", "test-org", "other")).ToNot(Succeed())
+		Expect(client.CreateEnvironmentVariable(id, "FOO", "bar")).ToNot(Succeed())
+		Expect(client.DeleteEnvironmentVariable(id, "FOO")).ToNot(Succeed())
+		Expect(client.DeleteContext(id)).ToNot(Succeed())
+		Expect(events).To(BeEmpty())
+	})
+})
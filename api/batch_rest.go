@@ -0,0 +1,391 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const defaultBatchConcurrency = 4
+
+type batchOptions struct {
+	failFast    bool
+	concurrency int
+}
+
+func newBatchOptions(opts []BatchOption) batchOptions {
+	options := batchOptions{
+		concurrency: defaultBatchConcurrency,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.concurrency < 1 {
+		options.concurrency = 1
+	}
+	return options
+}
+
+// A BatchOption configures how a batch operation such as BatchDeleteContexts
+// runs.
+type BatchOption func(*batchOptions)
+
+// WithFailFast controls what happens when one item of a batch fails. When
+// failFast is true, the first error cancels the remaining work and is returned
+// immediately. When false (the default), every item is attempted and all of
+// the errors are returned together as a *BatchError.
+func WithFailFast(failFast bool) BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = failFast
+	}
+}
+
+// WithConcurrency sets how many requests a batch operation may have in flight
+// at once.
+func WithConcurrency(concurrency int) BatchOption {
+	return func(o *batchOptions) {
+		o.concurrency = concurrency
+	}
+}
+
+// BatchError collects the errors of a batch operation, keyed by the ID of the
+// item that failed.
+type BatchError struct {
+	Errors map[string]error
+}
+
+func (e *BatchError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	messages := make([]string, 0, len(ids))
+	for _, id := range ids {
+		messages = append(messages, fmt.Sprintf("%s: %s", id, e.Errors[id]))
+	}
+	return fmt.Sprintf("%d operations failed: %s", len(ids), strings.Join(messages, "; "))
+}
+
+// AllEnvironmentVariables returns the environment variables of every context
+// owned by the given org, keyed by context ID. If some contexts fail, the
+// variables of the others are still returned alongside the error.
+func (c *ContextRestClient) AllEnvironmentVariables(vcs, org string, opts ...BatchOption) (map[string][]EnvironmentVariable, error) {
+	contexts, err := c.Contexts(vcs, org)
+	if err != nil {
+		return nil, err
+	}
+	return c.environmentVariablesOf(*contexts, opts)
+}
+
+// environmentVariablesOf lists the environment variables of each of the
+// given contexts concurrently, keyed by context ID.
+func (c *ContextRestClient) environmentVariablesOf(contexts []Context, opts []BatchOption) (map[string][]EnvironmentVariable, error) {
+	ids := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		ids = append(ids, context.ID)
+	}
+
+	var mu sync.Mutex
+	envVars := make(map[string][]EnvironmentVariable, len(ids))
+	err := runBatch(context.Background(), ids, opts, func(ctx context.Context, contextID string) error {
+		vars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+			ContextID: &contextID,
+		})
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		envVars[contextID] = vars
+		mu.Unlock()
+		return nil
+	})
+	return envVars, err
+}
+
+// EnvironmentVariablesForContexts lists the environment variables of each of
+// the given contexts, with at most concurrency requests in flight at once.
+// The variables are keyed by context ID, as are the errors of the contexts
+// which could not be listed; a context appears in only one of the two maps.
+// Configure the client WithRetries to retry requests which are rate limited.
+// Cancelling ctx stops the listing, and the contexts which were not listed
+// fail with its error.
+func (c *ContextRestClient) EnvironmentVariablesForContexts(ctx context.Context, contextIDs []string, concurrency int) (map[string][]EnvironmentVariable, map[string]error) {
+	seen := make(map[string]bool, len(contextIDs))
+	unique := make([]string, 0, len(contextIDs))
+	for _, id := range contextIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	var mu sync.Mutex
+	envVars := make(map[string][]EnvironmentVariable, len(unique))
+	errs := runEach(ctx, unique, concurrency, func(ctx context.Context, contextID string) error {
+		vars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+			ContextID: &contextID,
+		})
+		if err == nil {
+			mu.Lock()
+			envVars[contextID] = vars
+			mu.Unlock()
+		}
+		return err
+	})
+	return envVars, errs
+}
+
+// ContextEnvironmentVariables holds the environment variables of a single
+// context, as streamed by StreamEnvironmentVariables. If Err is set, the
+// variables could not be listed; if Context is also zero, the contexts
+// themselves could not be listed.
+type ContextEnvironmentVariables struct {
+	Context   Context
+	Variables []EnvironmentVariable
+	Err       error
+}
+
+// StreamEnvironmentVariables lists the environment variables of every context
+// owned by the given org, sending each context's variables on the returned
+// channel as soon as they have been listed, so that callers can process them
+// incrementally without holding every variable in memory. Contexts are listed
+// concurrently, as configured by WithConcurrency; WithFailFast is ignored,
+// since each result carries its own error. The channel is closed once every
+// context has been sent, or once ctx is cancelled.
+func (c *ContextRestClient) StreamEnvironmentVariables(ctx context.Context, vcs, org string, opts ...BatchOption) <-chan ContextEnvironmentVariables {
+	options := newBatchOptions(opts)
+	results := make(chan ContextEnvironmentVariables, options.concurrency)
+	send := func(result ContextEnvironmentVariables) {
+		select {
+		case results <- result:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(results)
+		contexts, err := c.listAllContexts(ctx, c.ownerParams(vcs, org))
+		if err != nil {
+			send(ContextEnvironmentVariables{Err: err})
+			return
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, options.concurrency)
+		for _, context := range contexts {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return
+			}
+
+			context := context
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { <-sem }()
+				vars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+					ContextID: &context.ID,
+				})
+				send(ContextEnvironmentVariables{Context: context, Variables: vars, Err: err})
+			}()
+		}
+		wg.Wait()
+	}()
+	return results
+}
+
+// An OrgRef identifies an org by its vcs and name, or its ID for GitLab
+// organizations.
+type OrgRef struct {
+	VCS string
+	Org string
+}
+
+func (o OrgRef) String() string {
+	return o.VCS + "/" + o.Org
+}
+
+// ContextsForOrgs returns the contexts owned by each of the given orgs,
+// listing the orgs concurrently as configured by opts. If some orgs fail, the
+// contexts of the others are still returned alongside a *BatchError keyed by
+// each failed org's String. Configure the client WithRetries to retry
+// requests which are rate limited. Cancelling ctx stops the listing, and its
+// error is returned.
+func (c *ContextRestClient) ContextsForOrgs(ctx context.Context, orgs []OrgRef, opts ...BatchOption) (map[OrgRef][]Context, error) {
+	refs := make(map[string]OrgRef, len(orgs))
+	ids := make([]string, 0, len(orgs))
+	for _, org := range orgs {
+		refs[org.String()] = org
+		ids = append(ids, org.String())
+	}
+
+	var mu sync.Mutex
+	contexts := make(map[OrgRef][]Context, len(orgs))
+	err := runBatch(ctx, ids, opts, func(ctx context.Context, id string) error {
+		org := refs[id]
+		orgContexts, err := c.listAllContexts(ctx, c.ownerParams(org.VCS, org.Org))
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		contexts[org] = orgContexts
+		mu.Unlock()
+		return nil
+	})
+	return contexts, err
+}
+
+// BatchDeleteContexts deletes each of the given contexts concurrently.
+func (c *ContextRestClient) BatchDeleteContexts(contextIDs []string, opts ...BatchOption) error {
+	return runBatch(context.Background(), contextIDs, opts, c.deleteContext)
+}
+
+// BatchDeleteContextsByName deletes each of the org's contexts with the given
+// names, with at most concurrency requests in flight at once. The names are
+// resolved to IDs from a single listing of the org's contexts, rather than one
+// lookup per name. The errors of the contexts which could not be deleted are
+// keyed by name; a name which doesn't match any context fails with a
+// NotFoundError, without stopping the others from being deleted. The error is
+// only set if the contexts could not be listed. Cancelling ctx stops the
+// deletion, and the contexts which were not deleted fail with its error.
+func (c *ContextRestClient) BatchDeleteContextsByName(ctx context.Context, vcs, org string, names []string, concurrency int) (map[string]error, error) {
+	ids, err := c.contextNameIndex(ctx, vcs, org)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+	return runEach(ctx, unique, concurrency, func(ctx context.Context, name string) error {
+		id, ok := ids[name]
+		if !ok {
+			return &NotFoundError{Message: fmt.Sprintf("Cannot find context named '%s'", name)}
+		}
+		return c.deleteContext(ctx, id)
+	}), nil
+}
+
+// DeleteAllEnvironmentVariables deletes every environment variable in the
+// context, with at most concurrency requests in flight at once, leaving the
+// context itself in place. The errors of the variables which could not be
+// deleted are keyed by variable name; the error is only set if the variables
+// could not be listed. Cancelling ctx stops the deletion, and the variables
+// which were not deleted fail with its error.
+func (c *ContextRestClient) DeleteAllEnvironmentVariables(ctx context.Context, contextID string, concurrency int) (map[string]error, error) {
+	envVars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+		ContextID: &contextID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(envVars))
+	for _, envVar := range envVars {
+		names = append(names, envVar.Variable)
+	}
+	return runEach(ctx, names, concurrency, func(ctx context.Context, variable string) error {
+		return c.deleteEnvironmentVariable(ctx, contextID, variable)
+	}), nil
+}
+
+// runEach calls fn for each of the ids, with at most concurrency calls in
+// flight at once, and returns the errors of the ids which failed. Unlike
+// runBatch, every id is attempted; if ctx is cancelled, the ids which were not
+// attempted fail with its error.
+func runEach(ctx context.Context, ids []string, concurrency int, fn func(ctx context.Context, id string) error) map[string]error {
+	var mu sync.Mutex
+	attempted := make(map[string]bool, len(ids))
+	errs := map[string]error{}
+	err := runBatch(ctx, ids, []BatchOption{WithConcurrency(concurrency)}, func(ctx context.Context, id string) error {
+		err := fn(ctx, id)
+		mu.Lock()
+		defer mu.Unlock()
+		attempted[id] = true
+		if err != nil {
+			errs[id] = err
+		}
+		return nil
+	})
+	if err != nil {
+		for _, id := range ids {
+			if !attempted[id] {
+				errs[id] = err
+			}
+		}
+	}
+	return errs
+}
+
+// runBatch calls fn for each of the ids with bounded concurrency, following
+// the semantics described by WithFailFast. If ctx is cancelled, no further
+// work is started and ctx's error is returned.
+func runBatch(ctx context.Context, ids []string, opts []BatchOption, fn func(ctx context.Context, id string) error) error {
+	options := newBatchOptions(opts)
+
+	parent, cancel := context.WithCancel(ctx)
+	defer cancel()
+	g, groupCtx := errgroup.WithContext(parent)
+
+	var mu sync.Mutex
+	var firstErr error
+	failures := map[string]error{}
+	sem := make(chan struct{}, options.concurrency)
+
+	for _, id := range ids {
+		sem <- struct{}{}
+		if groupCtx.Err() != nil {
+			break
+		}
+
+		id := id
+		g.Go(func() error {
+			defer func() { <-sem }()
+
+			err := fn(groupCtx, id)
+			if err == nil {
+				return nil
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if !options.failFast {
+				failures[id] = err
+				return nil
+			}
+			// Record the error and cancel before releasing our slot, so that
+			// no further work is started and the cancellation errors of
+			// in-flight requests don't mask the original failure.
+			if firstErr == nil {
+				firstErr = err
+			}
+			cancel()
+			return err
+		})
+	}
+
+	_ = g.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return &BatchError{Errors: failures}
+	}
+	return nil
+}
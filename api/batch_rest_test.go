@@ -0,0 +1,403 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Batch operations", func() {
+	var (
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+		ids    []string
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+		ids = []string{
+			fake.addContext("first", time.Now(), "A"),
+			fake.addContext("second", time.Now(), "B"),
+			fake.addContext("third", time.Now(), "C"),
+		}
+		fake.fail = func(req *http.Request) int {
+			if strings.HasPrefix(req.URL.Path, "/api/v2/context/"+ids[1]) {
+				return http.StatusInternalServerError
+			}
+			return 0
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.Describe("BatchDeleteContexts", func() {
+		ginkgo.It("attempts every context and collects all errors by default", func() {
+			err := client.BatchDeleteContexts(ids)
+			Expect(err).To(BeAssignableToTypeOf(&BatchError{}))
+			Expect(err.(*BatchError).Errors).To(HaveLen(1))
+			Expect(err.(*BatchError).Errors).To(HaveKey(ids[1]))
+			Expect(fake.Requests()).To(HaveLen(3))
+
+			contexts, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(1))
+			Expect((*contexts)[0].ID).To(Equal(ids[1]))
+		})
+
+		ginkgo.It("stops at the first error when failing fast", func() {
+			err := client.BatchDeleteContexts(ids, WithFailFast(true), WithConcurrency(1))
+			Expect(err).To(MatchError("Internal Server Error"))
+			Expect(fake.Requests()).To(Equal([]string{
+				"DELETE /api/v2/context/" + ids[0],
+				"DELETE /api/v2/context/" + ids[1],
+			}))
+		})
+	})
+
+	ginkgo.Describe("BatchDeleteContextsByName", func() {
+		ginkgo.It("deletes the named contexts and reports the missing ones", func() {
+			fake.fail = nil
+			errs, err := client.BatchDeleteContextsByName(context.Background(), "gh", "test-org", []string{"first", "missing", "third", "first"}, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs).ToNot(HaveKey("first"))
+			Expect(errs).ToNot(HaveKey("third"))
+			Expect(IsNotFoundError(errs["missing"])).To(BeTrue())
+			Expect(errs["missing"]).To(MatchError("Cannot find context named 'missing'"))
+
+			contexts, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(1))
+			Expect((*contexts)[0].Name).To(Equal("second"))
+		})
+
+		ginkgo.It("lists the contexts once and deletes each by ID", func() {
+			for i := 0; i < 3; i++ {
+				fake.addContext(fmt.Sprintf("extra-%d", i), time.Now())
+			}
+			errs, err := client.BatchDeleteContextsByName(context.Background(), "gh", "test-org", []string{"first", "third"}, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).To(BeEmpty())
+			Expect(fake.Requests()).To(Equal([]string{
+				"GET /api/v2/context",
+				"GET /api/v2/context",
+				"GET /api/v2/context",
+				"DELETE /api/v2/context/" + ids[0],
+				"DELETE /api/v2/context/" + ids[2],
+			}))
+		})
+
+		ginkgo.It("reports contexts which fail to delete by name", func() {
+			errs, err := client.BatchDeleteContextsByName(context.Background(), "gh", "test-org", []string{"first", "second"}, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs).To(HaveKeyWithValue("second", MatchError("Internal Server Error")))
+		})
+
+		ginkgo.It("fails when the contexts cannot be listed", func() {
+			fake.fail = func(req *http.Request) int {
+				return http.StatusInternalServerError
+			}
+			errs, err := client.BatchDeleteContextsByName(context.Background(), "gh", "test-org", []string{"first"}, 2)
+			Expect(err).To(MatchError("Internal Server Error"))
+			Expect(errs).To(BeNil())
+		})
+	})
+
+	ginkgo.Describe("DeleteAllEnvironmentVariables", func() {
+		ginkgo.It("deletes every variable in the context", func() {
+			id := fake.addContext("many", time.Now(), "W", "X", "Y", "Z", "ZZ")
+			errs, err := client.DeleteAllEnvironmentVariables(context.Background(), id, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).To(BeEmpty())
+
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*envVars).To(BeEmpty())
+
+			contexts, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(4))
+		})
+
+		ginkgo.It("collects the errors of the variables which fail", func() {
+			id := fake.addContext("many", time.Now(), "KEEP", "GONE")
+			fake.fail = func(req *http.Request) int {
+				if strings.HasSuffix(req.URL.Path, "/KEEP") {
+					return http.StatusInternalServerError
+				}
+				return 0
+			}
+			errs, err := client.DeleteAllEnvironmentVariables(context.Background(), id, 2)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).To(HaveLen(1))
+			Expect(errs).To(HaveKeyWithValue("KEEP", MatchError("Internal Server Error")))
+
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*envVars).To(HaveLen(1))
+		})
+
+		ginkgo.It("stops when the context is cancelled", func() {
+			id := fake.addContext("many", time.Now(), "KEEP", "ALSO_KEEP")
+			ctx, cancel := context.WithCancel(context.Background())
+			fake.fail = func(req *http.Request) int {
+				// Cancel while deleting the first variable.
+				if req.Method == "DELETE" {
+					cancel()
+				}
+				return 0
+			}
+
+			errs, err := client.DeleteAllEnvironmentVariables(ctx, id, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(errs).ToNot(BeEmpty())
+			for _, err := range errs {
+				Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			}
+
+			fake.fail = nil
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*envVars).ToNot(BeEmpty())
+		})
+
+		ginkgo.It("fails when the variables cannot be listed", func() {
+			errs, err := client.DeleteAllEnvironmentVariables(context.Background(), ids[1], 2)
+			Expect(err).To(MatchError("Internal Server Error"))
+			Expect(errs).To(BeNil())
+		})
+	})
+
+	ginkgo.Describe("AllEnvironmentVariables", func() {
+		ginkgo.It("returns partial results and collects all errors by default", func() {
+			envVars, err := client.AllEnvironmentVariables("gh", "test-org")
+			Expect(err).To(BeAssignableToTypeOf(&BatchError{}))
+			Expect(err.(*BatchError).Errors).To(HaveKey(ids[1]))
+			Expect(envVars).To(HaveLen(2))
+			Expect(envVars[ids[0]][0].Variable).To(Equal("A"))
+			Expect(envVars[ids[2]][0].Variable).To(Equal("C"))
+		})
+
+		ginkgo.It("stops at the first error when failing fast", func() {
+			_, err := client.AllEnvironmentVariables("gh", "test-org", WithFailFast(true), WithConcurrency(1))
+			Expect(err).To(MatchError("Internal Server Error"))
+			Expect(fake.Requests()).ToNot(ContainElement("GET /api/v2/context/" + ids[2] + "/environment-variable"))
+		})
+	})
+})
+
+var _ = ginkgo.Describe("ContextsForOrgs", func() {
+	var server *httptest.Server
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			switch req.URL.Query().Get("owner-slug") {
+			case "gh/first-org":
+				_, _ = rw.Write([]byte(`{"items": [{"id": "1", "name": "first"}]}`))
+			case "bb/second-org":
+				_, _ = rw.Write([]byte(`{"items": [{"id": "2", "name": "second"}, {"id": "3", "name": "third"}]}`))
+			default:
+				rw.WriteHeader(http.StatusForbidden)
+				_, _ = rw.Write([]byte(`{"message": "Permission denied."}`))
+			}
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("lists the contexts of each org", func() {
+		client := newTestRestClient(server)
+		first := OrgRef{VCS: "gh", Org: "first-org"}
+		second := OrgRef{VCS: "bb", Org: "second-org"}
+
+		contexts, err := client.ContextsForOrgs(context.Background(), []OrgRef{first, second})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contexts).To(HaveLen(2))
+		Expect(contexts[first]).To(HaveLen(1))
+		Expect(contexts[first][0].Name).To(Equal("first"))
+		Expect(contexts[second]).To(HaveLen(2))
+	})
+
+	ginkgo.It("returns the other orgs' contexts when one fails", func() {
+		client := newTestRestClient(server)
+		first := OrgRef{VCS: "gh", Org: "first-org"}
+		forbidden := OrgRef{VCS: "gh", Org: "forbidden-org"}
+
+		contexts, err := client.ContextsForOrgs(context.Background(), []OrgRef{first, forbidden})
+		Expect(err).To(BeAssignableToTypeOf(&BatchError{}))
+		Expect(err.(*BatchError).Errors).To(HaveKeyWithValue("gh/forbidden-org", MatchError("Permission denied.")))
+		Expect(contexts).To(HaveKey(first))
+		Expect(contexts).ToNot(HaveKey(forbidden))
+	})
+
+	ginkgo.It("stops when the context is cancelled", func() {
+		client := newTestRestClient(server)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		contexts, err := client.ContextsForOrgs(ctx, []OrgRef{{VCS: "gh", Org: "first-org"}})
+		Expect(err).To(Equal(context.Canceled))
+		Expect(contexts).To(BeEmpty())
+	})
+})
+
+var _ = ginkgo.Describe("EnvironmentVariablesForContexts", func() {
+	var (
+		server   *httptest.Server
+		mu       sync.Mutex
+		inFlight int
+		most     int
+	)
+
+	ginkgo.BeforeEach(func() {
+		inFlight, most = 0, 0
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			inFlight++
+			if inFlight > most {
+				most = inFlight
+			}
+			mu.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			defer func() {
+				mu.Lock()
+				inFlight--
+				mu.Unlock()
+			}()
+
+			contextID := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v2/context/"), "/")[0]
+			if contextID == "forbidden" {
+				rw.WriteHeader(http.StatusForbidden)
+				_, _ = rw.Write([]byte(`{"message": "Permission denied."}`))
+				return
+			}
+			_, _ = fmt.Fprintf(rw, `{"items": [{"variable": "%s_VAR", "context_id": "%s"}]}`, strings.ToUpper(contextID), contextID)
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("lists the variables of several contexts", func() {
+		client := newTestRestClient(server)
+		envVars, errs := client.EnvironmentVariablesForContexts(context.Background(), []string{"a", "b", "c", "d", "e", "a"}, 2)
+		Expect(errs).To(BeEmpty())
+		Expect(envVars).To(HaveLen(5))
+		for _, id := range []string{"a", "b", "c", "d", "e"} {
+			Expect(envVars[id]).To(HaveLen(1))
+			Expect(envVars[id][0].Variable).To(Equal(strings.ToUpper(id) + "_VAR"))
+		}
+		Expect(most).To(BeNumerically("<=", 2))
+	})
+
+	ginkgo.It("reports the errors of each context", func() {
+		client := newTestRestClient(server)
+		envVars, errs := client.EnvironmentVariablesForContexts(context.Background(), []string{"a", "forbidden"}, 4)
+		Expect(envVars).To(HaveKey("a"))
+		Expect(envVars).ToNot(HaveKey("forbidden"))
+		Expect(errs).To(HaveLen(1))
+		Expect(errs).To(HaveKeyWithValue("forbidden", MatchError("Permission denied.")))
+	})
+
+	ginkgo.It("fails the remaining contexts when the context is cancelled", func() {
+		client := newTestRestClient(server)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		envVars, errs := client.EnvironmentVariablesForContexts(ctx, []string{"a", "b"}, 1)
+		Expect(envVars).To(BeEmpty())
+		Expect(errs).To(HaveLen(2))
+		Expect(errs["a"]).To(MatchError(context.Canceled))
+		Expect(errs["b"]).To(MatchError(context.Canceled))
+	})
+})
+
+var _ = ginkgo.Describe("StreamEnvironmentVariables", func() {
+	var (
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+		fake.addContext("first", time.Now(), "A", "B", "C")
+		fake.addContext("second", time.Now())
+		fake.addContext("third", time.Now(), "D")
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("streams the variables of every context and closes the channel", func() {
+		variables := map[string][]string{}
+		for result := range client.StreamEnvironmentVariables(context.Background(), "gh", "test-org", WithConcurrency(2)) {
+			Expect(result.Err).ToNot(HaveOccurred())
+			names := []string{}
+			for _, envVar := range result.Variables {
+				names = append(names, envVar.Variable)
+			}
+			variables[result.Context.Name] = names
+		}
+		Expect(variables).To(Equal(map[string][]string{
+			"first":  {"A", "B", "C"},
+			"second": {},
+			"third":  {"D"},
+		}))
+	})
+
+	ginkgo.It("sends the error of each context which fails", func() {
+		fake.fail = func(req *http.Request) int {
+			if strings.HasPrefix(req.URL.Path, "/api/v2/context/context-3/") {
+				return http.StatusInternalServerError
+			}
+			return 0
+		}
+		failed := map[string]error{}
+		for result := range client.StreamEnvironmentVariables(context.Background(), "gh", "test-org") {
+			if result.Err != nil {
+				failed[result.Context.Name] = result.Err
+			}
+		}
+		Expect(failed).To(HaveLen(1))
+		Expect(failed["third"]).To(MatchError("Internal Server Error"))
+	})
+
+	ginkgo.It("sends the error if the contexts can't be listed", func() {
+		fake.fail = func(req *http.Request) int { return http.StatusForbidden }
+		var results []ContextEnvironmentVariables
+		for result := range client.StreamEnvironmentVariables(context.Background(), "gh", "test-org") {
+			results = append(results, result)
+		}
+		Expect(results).To(HaveLen(1))
+		Expect(results[0].Err).To(MatchError("Forbidden"))
+	})
+
+	ginkgo.It("closes the channel when cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		results := client.StreamEnvironmentVariables(ctx, "gh", "test-org", WithConcurrency(1))
+		<-results
+		cancel()
+		Eventually(func() bool {
+			_, open := <-results
+			return open
+		}).Should(BeFalse())
+	})
+})
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned, without a request being sent, while the circuit
+// breaker configured by WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("Not sending the request: the CircleCI API has failed too many times in a row")
+
+// WithCircuitBreaker makes the client fail fast during outages. After
+// threshold consecutive attempts fail with a server error or a network
+// failure, the breaker opens and every request fails immediately with
+// ErrCircuitOpen. Once cooldown has elapsed, the breaker half-opens: a single
+// request is let through to test whether the API has recovered, closing the
+// breaker if it succeeds and opening it for another cooldown if it fails.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.breaker = &circuitBreaker{
+			threshold: threshold,
+			cooldown:  cooldown,
+			now:       time.Now,
+		}
+	}
+}
+
+// circuitBreaker is safe for concurrent use.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	failures int
+	// openedAt is when the breaker last opened; it is zero while closed.
+	openedAt time.Time
+	// probing is set while the request testing a half-open breaker is in
+	// flight.
+	probing bool
+}
+
+// allow reports whether a request may be sent. Every allowed request must be
+// followed by a call to record.
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record records the outcome of an allowed request. A request which was
+// neither a success nor a failure, such as one cancelled by its caller,
+// leaves the breaker as it was.
+func (b *circuitBreaker) record(resp *http.Response, err error, inconclusive bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	wasProbing := b.probing
+	b.probing = false
+
+	switch {
+	case inconclusive:
+		return
+	case err != nil || resp.StatusCode >= 500:
+		b.failures++
+		if wasProbing || b.failures >= b.threshold {
+			b.openedAt = b.now()
+		}
+	default:
+		b.failures = 0
+		b.openedAt = time.Time{}
+	}
+}
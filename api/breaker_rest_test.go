@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Circuit breaker", func() {
+	var (
+		fake   *fakeContextAPI
+		client *ContextRestClient
+		server *httptest.Server
+		now    time.Time
+		status int
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer(WithCircuitBreaker(3, time.Minute))
+		now = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		client.breaker.now = func() time.Time { return now }
+		status = http.StatusServiceUnavailable
+		fake.fail = func(*http.Request) int { return status }
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	openBreaker := func() {
+		for i := 0; i < 3; i++ {
+			Expect(client.DeleteContext("context-id")).To(MatchError("Service Unavailable"))
+		}
+	}
+
+	ginkgo.It("opens after consecutive failures and fails fast", func() {
+		openBreaker()
+		Expect(client.DeleteContext("context-id")).To(Equal(ErrCircuitOpen))
+		Expect(fake.Requests()).To(HaveLen(3))
+	})
+
+	ginkgo.It("stays closed while failures are interrupted by successes", func() {
+		for i := 0; i < 3; i++ {
+			Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+			Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+			status = 0
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			status = http.StatusServiceUnavailable
+		}
+		Expect(fake.Requests()).To(HaveLen(9))
+	})
+
+	ginkgo.It("does not count client errors as failures", func() {
+		status = http.StatusNotFound
+		for i := 0; i < 5; i++ {
+			Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+		}
+		Expect(fake.Requests()).To(HaveLen(5))
+	})
+
+	ginkgo.It("closes once a request succeeds after the cooldown", func() {
+		openBreaker()
+		now = now.Add(time.Minute)
+		status = 0
+
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(fake.Requests()).To(HaveLen(5))
+	})
+
+	ginkgo.It("opens again when the half-open test request fails", func() {
+		openBreaker()
+		now = now.Add(time.Minute)
+
+		Expect(client.DeleteContext("context-id")).To(MatchError("Service Unavailable"))
+		Expect(client.DeleteContext("context-id")).To(Equal(ErrCircuitOpen))
+		Expect(fake.Requests()).To(HaveLen(4))
+
+		now = now.Add(time.Minute)
+		status = 0
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+	})
+
+	ginkgo.It("lets a single test request through while half-open", func() {
+		openBreaker()
+		now = now.Add(time.Minute)
+
+		Expect(client.breaker.allow()).To(BeTrue())
+		Expect(client.DeleteContext("context-id")).To(Equal(ErrCircuitOpen))
+	})
+})
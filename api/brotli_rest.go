@@ -0,0 +1,19 @@
+//go:build brotli
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/andybalholm/brotli"
+)
+
+func decodeBrotli(bodyBytes []byte) ([]byte, error) {
+	decoded, err := ioutil.ReadAll(brotli.NewReader(bytes.NewReader(bodyBytes)))
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decompress the Brotli-encoded response: %w", err)
+	}
+	return decoded, nil
+}
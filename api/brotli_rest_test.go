@@ -0,0 +1,43 @@
+//go:build brotli
+
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/andybalholm/brotli"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Brotli responses", func() {
+	var server *httptest.Server
+
+	ginkgo.BeforeEach(func() {
+		var encoded bytes.Buffer
+		writer := brotli.NewWriter(&encoded)
+		_, err := writer.Write([]byte(`{"id": "context-id", "name": "deploy"}`))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(writer.Close()).To(Succeed())
+
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Type", "application/json")
+			rw.Header().Set("Content-Encoding", "br")
+			_, _ = rw.Write(encoded.Bytes())
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("decompresses a br-encoded body", func() {
+		context, err := newTestRestClient(server).GetContextByID("context-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(context.Name).To(Equal("deploy"))
+	})
+})
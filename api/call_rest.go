@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type callOptions struct {
+	timeout     time.Duration
+	maxAttempts int
+}
+
+// A CallOption overrides the client's settings for a single call. Settings
+// which aren't overridden keep the client's values.
+type CallOption func(*callOptions)
+
+// WithCallTimeout limits how long the whole call may take, including any
+// retries and, for list methods, the fetching of every page.
+func WithCallTimeout(timeout time.Duration) CallOption {
+	return func(o *callOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithCallRetries overrides WithRetries for a single call, making at most
+// maxAttempts attempts at each request.
+func WithCallRetries(maxAttempts int) CallOption {
+	return func(o *callOptions) {
+		o.maxAttempts = maxAttempts
+	}
+}
+
+type callOptionsKey struct{}
+
+// newCallContext returns a context carrying the given call options, which the
+// call's requests should be sent with. The cancel function must be called
+// once the call has finished.
+func newCallContext(opts []CallOption) (context.Context, context.CancelFunc) {
+	var options callOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx := context.WithValue(context.Background(), callOptionsKey{}, &options)
+	if options.timeout > 0 {
+		return context.WithTimeout(ctx, options.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// attemptsFor returns how many attempts may be made at sending req.
+func (c *ContextRestClient) attemptsFor(req *http.Request) int {
+	if options, ok := req.Context().Value(callOptionsKey{}).(*callOptions); ok && options.maxAttempts > 0 {
+		return options.maxAttempts
+	}
+	return c.maxAttempts
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Call options", func() {
+	var (
+		server   *httptest.Server
+		delay    time.Duration
+		failures int32
+		requests int32
+	)
+
+	ginkgo.BeforeEach(func() {
+		delay = 0
+		failures = 0
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			atomic.AddInt32(&requests, 1)
+			if atomic.AddInt32(&failures, -1) >= 0 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = rw.Write([]byte(`{"message": "Service Unavailable"}`))
+				return
+			}
+			select {
+			case <-time.After(delay):
+			case <-req.Context().Done():
+				return
+			}
+			_, _ = rw.Write([]byte(`{"id": "pipeline-id", "number": 1}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("overrides the timeout of a single call", func() {
+		delay = 200 * time.Millisecond
+		client := newTestRestClient(server)
+
+		_, err := client.TriggerPipeline("gh", "test-org", "test-project", "main", nil, WithCallTimeout(20*time.Millisecond))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+
+		pipeline, err := client.TriggerPipeline("gh", "test-org", "test-project", "main", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(pipeline.ID).To(Equal("pipeline-id"))
+	})
+
+	ginkgo.It("applies the timeout across every page of a list", func() {
+		delay = 200 * time.Millisecond
+		client := newTestRestClient(server)
+		_, err := client.ListPipelinesForProject("gh", "test-org", "test-project", PipelineFilter{}, WithCallTimeout(20*time.Millisecond))
+		Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+
+	ginkgo.It("overrides the retries of a single call", func() {
+		client := newTestRestClient(server)
+		client.retryBaseDelay = time.Millisecond
+
+		failures = 1
+		_, err := client.GetOrganization("gh", "test-org")
+		Expect(err).To(MatchError("Service Unavailable"))
+		Expect(requests).To(Equal(int32(1)))
+
+		failures = 1
+		requests = 0
+		_, err = client.GetOrganization("gh", "test-org", WithCallRetries(2))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(Equal(int32(2)))
+	})
+})
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// A ConfigValidationResult reports whether a configuration is valid. The
+// compiled configuration is only set when it is.
+type ConfigValidationResult struct {
+	Valid      bool                    `json:"valid"`
+	SourceYAML string                  `json:"source-yaml"`
+	OutputYAML string                  `json:"output-yaml"`
+	Errors     []ConfigValidationError `json:"errors"`
+}
+
+// A ConfigValidationError describes one of the problems with an invalid
+// configuration. The API reports problems as messages only, without their
+// location in the configuration.
+type ConfigValidationError struct {
+	Message string `json:"message"`
+}
+
+// ValidateConfig asks CircleCI to compile configYAML, as it would for a
+// pipeline of one of the org's projects, so that private orbs and the org's
+// settings are taken into account. If org is empty, the configuration is
+// compiled without an owner. An invalid configuration is not an error: it is
+// reported in the result.
+func (c *ContextRestClient) ValidateConfig(vcs, org, configYAML string, opts ...CallOption) (*ConfigValidationResult, error) {
+	var ownerID string
+	if org != "" {
+		if isGitLab(vcs) {
+			ownerID = org
+		} else {
+			organization, err := c.GetOrganization(vcs, org, opts...)
+			if err != nil {
+				return nil, err
+			}
+			ownerID = organization.ID
+		}
+	}
+
+	req, err := c.newValidateConfigRequest(ownerID, configYAML)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	var dest ConfigValidationResult
+	if _, _, err := c.send(ctx, req, nil, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newValidateConfigRequest(ownerID, configYAML string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse("compile-config-with-defaults")
+	if err != nil {
+		return nil, err
+	}
+
+	type options struct {
+		OwnerID string `json:"owner_id,omitempty"`
+	}
+	body := struct {
+		ConfigYAML string  `json:"config_yaml"`
+		Options    options `json:"options"`
+	}{
+		ConfigYAML: configYAML,
+		Options:    options{OwnerID: ownerID},
+	}
+	return c.newJSONRequest("POST", queryURL.String(), body)
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ValidateConfig", func() {
+	var (
+		server *httptest.Server
+		owners chan string
+	)
+
+	ginkgo.BeforeEach(func() {
+		owners = make(chan string, 1)
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			defer ginkgo.GinkgoRecover()
+			switch req.URL.Path {
+			case "/api/v2/organization/gh/test-org":
+				_, _ = rw.Write([]byte(`{"id": "org-id", "name": "test-org"}`))
+			case "/api/v2/compile-config-with-defaults":
+				Expect(req.Method).To(Equal("POST"))
+				var body struct {
+					ConfigYAML string `json:"config_yaml"`
+					Options    struct {
+						OwnerID string `json:"owner_id"`
+					} `json:"options"`
+				}
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				owners <- body.Options.OwnerID
+				if strings.Contains(body.ConfigYAML, "jobs:") {
+					_, _ = rw.Write([]byte(`{"valid": true, "source-yaml": "version: 2.1\njobs: {}\n", "output-yaml": "version: 2\njobs: {}\n", "errors": []}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"valid": false, "source-yaml": "version: 2.1\n", "output-yaml": "", "errors": [{"message": "config compilation contains errors: jobs is required"}]}`))
+			default:
+				rw.WriteHeader(http.StatusNotFound)
+				_, _ = rw.Write([]byte(`{"message": "Not found"}`))
+			}
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("reports a valid config", func() {
+		result, err := newTestRestClient(server).ValidateConfig("gh", "test-org", "version: 2.1\njobs: {}\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeTrue())
+		Expect(result.OutputYAML).To(Equal("version: 2\njobs: {}\n"))
+		Expect(result.Errors).To(BeEmpty())
+		Expect(<-owners).To(Equal("org-id"))
+	})
+
+	ginkgo.It("reports the errors of an invalid config", func() {
+		result, err := newTestRestClient(server).ValidateConfig("gh", "test-org", "version: 2.1\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(result.Valid).To(BeFalse())
+		Expect(result.Errors).To(Equal([]ConfigValidationError{{Message: "config compilation contains errors: jobs is required"}}))
+	})
+
+	ginkgo.It("compiles without an owner when no org is given", func() {
+		_, err := newTestRestClient(server).ValidateConfig("gh", "", "version: 2.1\njobs: {}\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-owners).To(BeEmpty())
+	})
+
+	ginkgo.It("uses the org ID of GitLab organizations", func() {
+		_, err := newTestRestClient(server).ValidateConfig("circleci", "gitlab-org-id", "version: 2.1\njobs: {}\n")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-owners).To(Equal("gitlab-org-id"))
+	})
+})
@@ -1,22 +1,56 @@
 package api
 
 import (
+	"encoding/json"
 	"time"
 )
 
 // An EnvironmentVariable has a Variable, a ContextID (its owner), and a
-// CreatedAt date.
+// CreatedAt date, which is always in UTC.
 type EnvironmentVariable struct {
 	Variable string
 	ContextID string
 	CreatedAt time.Time
 }
 
-// A Context is the owner of EnvironmentVariables.
+// UnmarshalJSON decodes an EnvironmentVariable from either the REST API, which
+// uses snake_case keys, or the GraphQL API, which uses camelCase keys.
+func (v *EnvironmentVariable) UnmarshalJSON(data []byte) error {
+	var fields struct {
+		Variable         string     `json:"variable"`
+		ContextID        string     `json:"context_id"`
+		CreatedAt        *time.Time `json:"created_at"`
+		GraphQLCreatedAt *time.Time `json:"createdAt"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	v.Variable = fields.Variable
+	v.ContextID = fields.ContextID
+	switch {
+	case fields.CreatedAt != nil:
+		v.CreatedAt = *fields.CreatedAt
+	case fields.GraphQLCreatedAt != nil:
+		v.CreatedAt = *fields.GraphQLCreatedAt
+	}
+	v.CreatedAt = v.CreatedAt.UTC()
+	return nil
+}
+
+// A Context is the owner of EnvironmentVariables. CreatedAt is always in UTC,
+// whatever offset the API reported it with. CreatedBy is only set when the API
+// reports who created the context.
 type Context struct{
 	CreatedAt time.Time `json:"created_at"`
 	ID string `json:"id"`
 	Name string `json:"name"`
+	CreatedBy *ContextCreator `json:"created_by,omitempty"`
+}
+
+// A ContextCreator identifies the user who created a Context.
+type ContextCreator struct {
+	ID    string `json:"id"`
+	Login string `json:"login"`
 }
 
 // ContextInterface is the interface to interact with contexts and environment
@@ -226,7 +226,7 @@ func (c *GraphQLContextClient) Contexts(vcsType, orgName string) (*[]Context, er
 		contexts = append(contexts, Context{
 			Name:      context.Name,
 			ID:        context.ID,
-			CreatedAt: created_at,
+			CreatedAt: created_at.UTC(),
 		})
 	}
 
@@ -322,6 +322,7 @@ func (c *GraphQLContextClient) CreateEnvironmentVariable(contextId, variableName
 	input.Value = secretValue
 
 	request.Var("input", input)
+	request.Secret(secretValue)
 
 	var response struct {
 		StoreEnvironmentVariable struct {
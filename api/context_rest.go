@@ -2,24 +2,98 @@ package api
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"sort"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/settings"
 	"github.com/pkg/errors"
+	"golang.org/x/net/http2"
+	"golang.org/x/sync/errgroup"
 )
 
 // ContextRestClient communicates with the CircleCI REST API to ask questions
 // about contexts. It satisfies api.ContextInterface.
 type ContextRestClient struct {
-	token  string
-	server string
-	client *http.Client
+	token      TokenProvider
+	bearerAuth bool
+	server     string
+	appURL     string
+	client     *http.Client
+	// customClient is set when client was given WithHTTPClient, and so
+	// mustn't be tuned.
+	customClient bool
+
+	validateParameter PipelineParameterValidator
+	bodyReadTimeout   time.Duration
+	minimalResponses  bool
+	acceptHeader      string
+	encode            Encoder
+	buildSlug         SlugBuilder
+	strictDecoding    bool
+	gzipRequests      bool
+	gzipThreshold     int
+
+	sortEnvironmentVariables bool
+	filterContextsByName     bool
+
+	maxAttempts    int
+	retryBaseDelay time.Duration
+	retryMaxDelay  time.Duration
+	jitter         *lockedRand
+	retryBudget    *retryBudget
+	breaker        *circuitBreaker
+	retryPredicate RetryPredicate
+	rateLimit      *rateLimitTracker
+	lastRequestID  *requestIDTracker
+	ownerIDs       *ownerIDCache
+	etags          *etagCache
+	// after is time.After, replaced by tests with a fake clock.
+	after func(time.Duration) <-chan time.Time
+
+	auditSink      func(AuditEvent)
+	requestEditors []RequestEditor
+	startSpan      StartSpan
+	pageCallback   PageCallback
+
+	maxIdleConns         int
+	maxIdleConnsPerHost  int
+	http2ReadIdleTimeout time.Duration
+	http2PingTimeout     time.Duration
+	minTLSVersion        uint16
+	noProxy              bool
+	dialContext          func(ctx context.Context, network, addr string) (net.Conn, error)
+	// http2Transport is the HTTP/2 support configured on the client's
+	// transport by the HTTP/2 options, if any were given.
+	http2Transport *http2.Transport
+
+	// clientCtx is cancelled by CancelAll.
+	clientCtx context.Context
+	cancelAll context.CancelFunc
+}
+
+// A ContextRestOption configures optional behaviour of a ContextRestClient.
+type ContextRestOption func(*ContextRestClient)
+
+// WithPipelineParameterValidator replaces the validator TriggerPipeline runs
+// against each pipeline parameter. Passing nil disables client-side validation,
+// which is useful if CircleCI starts accepting new parameter types.
+func WithPipelineParameterValidator(validator PipelineParameterValidator) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.validateParameter = validator
+	}
 }
 
 type listEnvironmentVariablesResponse struct {
@@ -27,6 +101,7 @@ type listEnvironmentVariablesResponse struct {
 	NextPageToken *string `json:"next_page_token"`
 	client        *ContextRestClient
 	params        *listEnvironmentVariablesParams
+	rawItems      []json.RawMessage
 }
 
 type listContextsResponse struct {
@@ -34,142 +109,305 @@ type listContextsResponse struct {
 	NextPageToken *string `json:"next_page_token"`
 	client        *ContextRestClient
 	params        *listContextsParams
+	rawItems      []json.RawMessage
 }
 
 type errorResponse struct {
 	Message *string `json:"message"`
 }
 
+// WithBodyReadTimeout limits how long reading a response body may take, once
+// the response headers have been received. This protects against servers that
+// respond quickly but then send the body very slowly. A zero timeout, the
+// default, means no limit beyond that of the underlying http.Client.
+func WithBodyReadTimeout(timeout time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.bodyReadTimeout = timeout
+	}
+}
+
+// WithStrictDecoding makes decoding a response fail if it contains fields
+// which the type it is decoded into doesn't know about. It is off by default,
+// so that new fields added by CircleCI are tolerated in production, but
+// turning it on in tests catches changes to the API's schema. Fields of types
+// with their own decoding, such as EnvironmentVariable, are not checked.
+func WithStrictDecoding() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.strictDecoding = true
+	}
+}
+
+// defaultAcceptHeader is the media type the client accepts unless configured
+// WithAcceptHeader.
+const defaultAcceptHeader = "application/json"
+
+// WithAcceptHeader sets the Accept header sent with every request, such as
+// "application/vnd.circleci.v2+json" to ask for a particular version of the
+// API's media type. The default is "application/json". Responses are decoded
+// as JSON whatever the header asks for.
+func WithAcceptHeader(accept string) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.acceptHeader = accept
+	}
+}
+
+// WithMinimalResponses asks the server for minimal representations of the
+// items of list endpoints, by sending a "Prefer: return=minimal" header. This
+// is a best-effort bandwidth optimization: servers which don't support the
+// header ignore it and return full representations as usual.
+func WithMinimalResponses() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.minimalResponses = true
+	}
+}
+
+// WithServerSideNameFilter makes ContextByName ask the server to filter the
+// contexts it lists by name, by sending a "name" query parameter, so that
+// looking up a context in a large org doesn't list all of its contexts. The
+// listed contexts are still matched by name on the client, so servers which
+// ignore the parameter return the same result, just more slowly.
+func WithServerSideNameFilter() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.filterContextsByName = true
+	}
+}
+
+// WithEnvironmentVariablesByCreation makes the methods listing a context's
+// environment variables, such as EnvironmentVariables, return them sorted
+// by creation time, oldest first, which shows the order in which they were
+// last rotated. By default they are returned in the order the API lists them.
+func WithEnvironmentVariablesByCreation() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.sortEnvironmentVariables = true
+	}
+}
+
 type listContextsParams struct {
 	OwnerID   *string
 	OwnerSlug *string
 	OwnerType *string
 	PageToken *string
+	Name      *string
+
+	// keepRaw asks for the raw JSON of each item to be kept in the response.
+	keepRaw bool
 }
 
 type listEnvironmentVariablesParams struct {
 	ContextID *string
 	PageToken *string
+
+	// keepRaw asks for the raw JSON of each item to be kept in the response.
+	keepRaw bool
+}
+
+type rawItemsResponse struct {
+	Items []json.RawMessage `json:"items"`
+}
+
+// A SlugBuilder builds the slug identifying an org, such as "gh/test-org",
+// from its vcs and name.
+type SlugBuilder func(vcs, org string) string
+
+// WithSlugBuilder replaces how the client builds org slugs, for providers
+// whose slugs aren't "vcs/org". Project slugs are the org slug followed by
+// "/project". GitLab organizations, which are identified by ID, aren't
+// affected.
+func WithSlugBuilder(build SlugBuilder) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.buildSlug = build
+	}
+}
+
+func defaultSlug(vcs, org string) string {
+	return fmt.Sprintf("%s/%s", vcs, org)
 }
 
-func toSlug(vcs, org string) *string {
-	slug := fmt.Sprintf("%s/%s", vcs, org)
+func (c *ContextRestClient) toSlug(vcs, org string) *string {
+	slug := c.buildSlug(vcs, org)
 	return &slug
 }
 
+// isGitLab reports whether vcs names CircleCI's GitLab integration, whose
+// organizations are identified by ID rather than by a vcs/org slug.
+func isGitLab(vcs string) bool {
+	switch strings.ToLower(vcs) {
+	case "gitlab", "gl", "circleci":
+		return true
+	}
+	return false
+}
+
+// ownerParams returns the params identifying the owner of contexts. For
+// GitLab, org is expected to be the organization ID.
+func (c *ContextRestClient) ownerParams(vcs, org string) *listContextsParams {
+	if isGitLab(vcs) {
+		return &listContextsParams{OwnerID: &org}
+	}
+	return &listContextsParams{OwnerSlug: c.toSlug(vcs, org)}
+}
+
 // DeleteEnvironmentVariable deletes the environment variable in the context. It
 // does not return an error if the environment variable did not exist.
 func (c *ContextRestClient) DeleteEnvironmentVariable(contextID, variable string) error {
-	req, err := c.newDeleteEnvironmentVariableRequest(contextID, variable)
-	if err != nil {
-		return err
-	}
+	return c.deleteEnvironmentVariable(context.Background(), contextID, variable)
+}
 
-	resp, err := c.client.Do(req)
+func (c *ContextRestClient) deleteEnvironmentVariable(ctx context.Context, contextID, variable string) error {
+	req, err := c.newDeleteEnvironmentVariableRequest(contextID, variable)
 	if err != nil {
 		return err
 	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
+	if _, _, err := c.send(ctx, req, nil, nil); err != nil {
 		return err
 	}
-
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return err
-		}
-		return errors.New(*dest.Message)
-	}
+	c.audit(AuditEvent{
+		Operation:  AuditDeleteEnvironmentVariable,
+		Parameters: map[string]string{"context_id": contextID, "variable": variable},
+		ResourceID: variable,
+	})
 	return nil
 }
 
 // CreateContext creates a new context in the supplied organization.
 func (c *ContextRestClient) CreateContext(vcs, org, name string) error {
-	req, err := c.newCreateContextRequest(vcs, org, name)
-	if err != nil {
-		return err
+	_, err := c.createContext(context.Background(), vcs, org, name)
+	if err == ErrEmptyResponseBody {
+		// The context was created; we just weren't told its details.
+		return nil
 	}
+	return err
+}
 
-	resp, err := c.client.Do(req)
+func (c *ContextRestClient) createContext(ctx context.Context, vcs, org, name string) (*Context, error) {
+	if err := ValidateContextName(name); err != nil {
+		return nil, err
+	}
 
+	req, err := c.newCreateContextRequest(vcs, org, name)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
-	if err != nil {
-		return err
+	var dest Context
+	_, _, err = c.send(ctx, req, nil, &dest)
+	if err != nil && err != ErrEmptyResponseBody {
+		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return err
+	if err == ErrEmptyResponseBody {
+		// The context was created, but not returned, so look it up to learn
+		// its ID. If it can't be found, the caller still learns that it was
+		// created from ErrEmptyResponseBody.
+		if found, lookupErr := c.contextByName(ctx, vcs, org, name); lookupErr == nil {
+			dest, err = *found, nil
 		}
-		return errors.New(*dest.Message)
 	}
-	var dest Context
-	if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-		return err
+	dest.CreatedAt = dest.CreatedAt.UTC()
+	c.audit(AuditEvent{
+		Operation:  AuditCreateContext,
+		Parameters: map[string]string{"vcs": vcs, "org": org, "name": name},
+		ResourceID: dest.ID,
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil
+	return &dest, nil
 }
 
 // CreateEnvironmentVariable creates OR UPDATES an environment variable.
 func (c *ContextRestClient) CreateEnvironmentVariable(contextID, variable, value string) error {
-	req, err := c.newCreateEnvironmentVariableRequest(contextID, variable, value)
-	if err != nil {
-		return err
+	_, err := c.PutEnvironmentVariable(contextID, variable, value)
+	if err == ErrEmptyResponseBody {
+		return nil
 	}
+	return err
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
+// PutEnvironmentVariable is like CreateEnvironmentVariable, but also returns
+// the environment variable as stored by the server, which confirms the
+// context it was stored in and when. The API never returns the value, not
+// even in masked form, once it has been stored.
+func (c *ContextRestClient) PutEnvironmentVariable(contextID, variable, value string) (*EnvironmentVariable, error) {
+	return c.putEnvironmentVariable(context.Background(), contextID, variable, value)
+}
+
+// RotateEnvironmentVariable replaces the value of an existing environment
+// variable with newValue, then reads the context's variables back to confirm
+// that the variable is stored, returning it as listed. The new value is never
+// logged or returned.
+func (c *ContextRestClient) RotateEnvironmentVariable(contextID, variable, newValue string) (*EnvironmentVariable, error) {
+	if _, err := c.PutEnvironmentVariable(contextID, variable, newValue); err != nil && err != ErrEmptyResponseBody {
+		return nil, err
 	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
+	envVars, err := c.EnvironmentVariables(contextID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return err
+	for _, envVar := range *envVars {
+		if envVar.Variable == variable {
+			return &envVar, nil
 		}
-		return errors.New(*dest.Message)
 	}
-	return nil
+	return nil, fmt.Errorf("Environment variable '%s' is missing from context %s after rotating it", variable, contextID)
 }
 
-// DeleteContext deletes the context with the given ID.
-func (c *ContextRestClient) DeleteContext(contextID string) error {
-	req, err := c.newDeleteContextRequest(contextID)
+func (c *ContextRestClient) putEnvironmentVariable(ctx context.Context, contextID, variable, value string) (*EnvironmentVariable, error) {
+	if err := ValidateEnvVarName(variable); err != nil {
+		return nil, err
+	}
+	if err := ValidateEnvVarValue(value); err != nil {
+		return nil, err
+	}
+	envVar, err := c.sendEnvironmentVariable(ctx, contextID, variable, value)
+	return envVar, redactError(err, value)
+}
 
+func (c *ContextRestClient) sendEnvironmentVariable(ctx context.Context, contextID, variable, value string) (*EnvironmentVariable, error) {
+	req, err := c.newCreateEnvironmentVariableRequest(contextID, variable, value)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	resp, err := c.client.Do(req)
+	resp, bodyBytes, err := c.send(ctx, req, nil, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	// The value is deliberately left out of the audit event.
+	c.audit(AuditEvent{
+		Operation:  AuditCreateEnvironmentVariable,
+		Parameters: map[string]string{"context_id": contextID, "variable": variable},
+		ResourceID: variable,
+	})
+
+	var dest EnvironmentVariable
+	if err := c.decodeBody(resp.StatusCode, bodyBytes, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+// DeleteContext deletes the context with the given ID.
+func (c *ContextRestClient) DeleteContext(contextID string) error {
+	return c.deleteContext(context.Background(), contextID)
+}
+
+func (c *ContextRestClient) deleteContext(ctx context.Context, contextID string) error {
+	req, err := c.newDeleteContextRequest(contextID)
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
 	if err != nil {
 		return err
 	}
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return err
-		}
-		return errors.New(*dest.Message)
+
+	if _, _, err := c.send(ctx, req, nil, nil); err != nil {
+		return err
 	}
+	c.audit(AuditEvent{
+		Operation:  AuditDeleteContext,
+		Parameters: map[string]string{"context_id": contextID},
+		ResourceID: contextID,
+	})
 	return nil
 }
 
@@ -178,6 +416,7 @@ func (c *ContextRestClient) DeleteContext(contextID string) error {
 // pages of env vars and return them all.
 func (c *ContextRestClient) EnvironmentVariables(contextID string) (*[]EnvironmentVariable, error) {
 	envVars, error := c.listAllEnvironmentVariables(
+		context.Background(),
 		&listEnvironmentVariablesParams{
 			ContextID: &contextID,
 		},
@@ -185,145 +424,512 @@ func (c *ContextRestClient) EnvironmentVariables(contextID string) (*[]Environme
 	return &envVars, error
 }
 
+// EnvironmentVariableCount returns how many environment variables the given
+// context has. Every page is still listed, but the variables aren't kept.
+func (c *ContextRestClient) EnvironmentVariableCount(contextID string, opts ...CallOption) (int, error) {
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	params := &listEnvironmentVariablesParams{
+		ContextID: &contextID,
+	}
+	return countItems(ctx, nil, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]EnvironmentVariable, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listEnvironmentVariables(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+}
+
+// ExportContextVariableNames returns the names of the environment variables
+// owned by the given context, sorted alphabetically. Values are never returned
+// by the API, so this is suitable for templating a .env file to be filled in
+// by hand.
+func (c *ContextRestClient) ExportContextVariableNames(contextID string) ([]string, error) {
+	envVars, err := c.EnvironmentVariables(contextID)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(*envVars))
+	for _, envVar := range *envVars {
+		names = append(names, envVar.Variable)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
 // Contexts returns all of the contexts owned by the given org. Note that
 // pagination is not currently supported - we get all pages of contexts and
-// return them all.
+// return them all. For GitLab organizations (vcs "gitlab" or "circleci"), org
+// must be the organization ID.
+//
+// There is no way to list only the contexts a particular project uses: the
+// API does not expose which contexts a project references, since that is
+// decided per job by the project's config. Auditing which contexts a project
+// can access therefore means reading its config.
 func (c *ContextRestClient) Contexts(vcs, org string) (*[]Context, error) {
-	contexts, error := c.listAllContexts(
-		&listContextsParams{
-			OwnerSlug: toSlug(vcs, org),
-		},
-	)
+	contexts, error := c.listAllContexts(context.Background(), c.ownerParams(vcs, org))
 	return &contexts, error
 }
 
-// ContextByName finds a single context by its name and returns it.
-func (c *ContextRestClient) ContextByName(vcs, org, name string) (*Context, error) {
-	params := &listContextsParams{
-		OwnerSlug: toSlug(vcs, org),
+// A ContextFilter selects which contexts ListContexts returns.
+type ContextFilter func(Context) bool
+
+// WithCreatedAfter selects contexts created strictly after the given time. The
+// API has no such filter, so it is applied client-side: every page of contexts
+// is still fetched.
+func WithCreatedAfter(after time.Time) ContextFilter {
+	return func(context Context) bool {
+		return context.CreatedAt.After(after)
 	}
-	for {
-		resp, err := c.listContexts(params)
+}
+
+// ListContexts returns the contexts owned by the given org which match all of
+// the given filters.
+func (c *ContextRestClient) ListContexts(vcs, org string, filters ...ContextFilter) (*[]Context, error) {
+	fetch := reportPages(c.pageCallback, c.contextPages(c.ownerParams(vcs, org)))
+	matching, err := paginate(context.Background(), nil, 0, func(ctx context.Context, pageToken *string) ([]Context, *string, error) {
+		items, next, err := fetch(ctx, pageToken)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
-		for _, context := range resp.Items {
-			if context.Name == name {
-				return &context, nil
+
+		var page []Context
+	items:
+		for _, context := range items {
+			for _, filter := range filters {
+				if !filter(context) {
+					continue items
+				}
 			}
+			page = append(page, context)
 		}
-		if resp.NextPageToken == nil {
-			return nil, fmt.Errorf("Cannot find context named '%s'", name)
-		}
-		params.PageToken = resp.NextPageToken
+		return page, next, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	contexts := append([]Context{}, matching...)
+	return &contexts, nil
 }
 
-func (c *ContextRestClient) listAllEnvironmentVariables(params *listEnvironmentVariablesParams) (envVars []EnvironmentVariable, err error) {
-	var resp *listEnvironmentVariablesResponse
-	for {
-		resp, err = c.listEnvironmentVariables(params)
-		if err != nil {
-			return nil, err
+// contextPrefetchPages is how many pages of contexts ForEachContext fetches
+// ahead of the callback.
+const contextPrefetchPages = 2
+
+// ForEachContext calls fn with each of the contexts owned by the given org, in
+// the order the API lists them, stopping at the first error fn returns. Pages
+// of contexts are fetched in the background while fn runs, so a slow fn isn't
+// also kept waiting on the network.
+func (c *ContextRestClient) ForEachContext(vcs, org string, fn func(Context) error) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	for page := range prefetchPages(ctx, nil, 0, contextPrefetchPages, reportPages(c.pageCallback, c.contextPages(c.ownerParams(vcs, org)))) {
+		if page.err != nil {
+			return page.err
 		}
+		for _, context := range page.items {
+			if err := fn(context); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
 
-		envVars = append(envVars, resp.Items...)
-
-		if resp.NextPageToken == nil {
-			break
+// SyncContexts supports incrementally mirroring an org's contexts into
+// another system. It returns the contexts created strictly after since, along
+// with the latest creation time seen, which should be passed as since on the
+// next sync; if there are no new contexts, since itself is returned. The API
+// doesn't report when a context was last modified, so only new contexts are
+// detected: renamed and deleted contexts still require a full listing.
+func (c *ContextRestClient) SyncContexts(vcs, org string, since time.Time) (*[]Context, time.Time, error) {
+	contexts, err := c.ListContexts(vcs, org, WithCreatedAfter(since))
+	if err != nil {
+		return nil, since, err
+	}
+	latest := since
+	for _, context := range *contexts {
+		if context.CreatedAt.After(latest) {
+			latest = context.CreatedAt
 		}
+	}
+	return contexts, latest, nil
+}
 
-		params.PageToken = resp.NextPageToken
+// ContextsFrom resumes listing the contexts owned by the given org from a page
+// token saved by an earlier, interrupted listing. It returns the contexts from
+// that page onwards. If listing fails part way through, the contexts fetched
+// so far are returned along with the token of the page that failed, so that a
+// later call can resume from there; once every page has been fetched the
+// returned token is empty.
+func (c *ContextRestClient) ContextsFrom(vcs, org, pageToken string) (*[]Context, string, error) {
+	if pageToken == "" {
+		return nil, "", errors.New("A page token is required to resume listing contexts")
+	}
+
+	// paginate drops the items fetched so far on error, so collect them here
+	// along with the token of the page being fetched.
+	fetch := reportPages(c.pageCallback, c.contextPages(c.ownerParams(vcs, org)))
+	contexts := []Context{}
+	current := pageToken
+	_, err := paginate(context.Background(), &pageToken, 0, func(ctx context.Context, pageToken *string) ([]Context, *string, error) {
+		current = *pageToken
+		items, next, err := fetch(ctx, pageToken)
+		contexts = append(contexts, items...)
+		return nil, next, err
+	})
+	if err != nil {
+		return &contexts, current, err
 	}
-	return envVars, nil
+	return &contexts, "", nil
 }
 
-func (c *ContextRestClient) listAllContexts(params *listContextsParams) (contexts []Context, err error) {
-	var resp *listContextsResponse
-	for {
-		resp, err = c.listContexts(params)
+// ContextsPaged returns a single page of the contexts owned by the given org,
+// along with its pagination metadata. Pass an empty pageToken for the first
+// page, then the NextPageToken of each page for the page after it.
+func (c *ContextRestClient) ContextsPaged(vcs, org, pageToken string) (*Page[Context], error) {
+	return fetchPage(context.Background(), pageToken, c.contextPages(c.ownerParams(vcs, org)))
+}
+
+// EnvironmentVariablesPaged returns a single page of the environment
+// variables owned by the given context, along with its pagination metadata,
+// as for ContextsPaged.
+func (c *ContextRestClient) EnvironmentVariablesPaged(contextID, pageToken string) (*Page[EnvironmentVariable], error) {
+	params := &listEnvironmentVariablesParams{
+		ContextID: &contextID,
+	}
+	return fetchPage(context.Background(), pageToken, func(ctx context.Context, pageToken *string) ([]EnvironmentVariable, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listEnvironmentVariables(ctx, params)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+		return resp.Items, resp.NextPageToken, nil
+	})
+}
+
+// ContextsByOwnerID returns all of the contexts owned by the organization with
+// the given ID. GitLab organizations have no vcs/org slug, so they can only be
+// addressed by ID.
+func (c *ContextRestClient) ContextsByOwnerID(ownerID string) (*[]Context, error) {
+	contexts, error := c.listAllContexts(context.Background(),
+		&listContextsParams{
+			OwnerID: &ownerID,
+		},
+	)
+	return &contexts, error
+}
 
-		contexts = append(contexts, resp.Items...)
+// ListContextsForAllOwnerTypes returns all of the contexts owned by the given
+// org, whether they are organization or account contexts. Both owner types are
+// queried concurrently and the results are merged, so that a context returned
+// for both appears only once.
+func (c *ContextRestClient) ListContextsForAllOwnerTypes(vcs, org string) (*[]Context, error) {
+	ownerTypes := []string{"organization", "account"}
+	results := make([][]Context, len(ownerTypes))
+
+	var g errgroup.Group
+	for i := range ownerTypes {
+		i := i
+		g.Go(func() error {
+			params := c.ownerParams(vcs, org)
+			params.OwnerType = &ownerTypes[i]
+			contexts, err := c.listAllContexts(context.Background(), params)
+			results[i] = contexts
+			return err
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
 
-		if resp.NextPageToken == nil {
-			break
+	seen := map[string]bool{}
+	contexts := []Context{}
+	for _, result := range results {
+		for _, context := range result {
+			if !seen[context.ID] {
+				seen[context.ID] = true
+				contexts = append(contexts, context)
+			}
 		}
-
-		params.PageToken = resp.NextPageToken
 	}
-	return contexts, nil
+	return &contexts, nil
 }
 
-func (c *ContextRestClient) listEnvironmentVariables(params *listEnvironmentVariablesParams) (*listEnvironmentVariablesResponse, error) {
-	req, err := c.newListEnvironmentVariablesRequest(params)
+// ContextByName finds a single context by its name and returns it.
+func (c *ContextRestClient) ContextByName(vcs, org, name string) (*Context, error) {
+	return c.contextByName(context.Background(), vcs, org, name)
+}
+
+// ContextNameIndex returns the IDs of the org's contexts keyed by name, built
+// from a single listing of its contexts, for callers which resolve many names.
+// Context names are unique within an org, so it returns an error rather than
+// guess if the API lists two contexts with the same name.
+func (c *ContextRestClient) ContextNameIndex(vcs, org string) (map[string]string, error) {
+	return c.contextNameIndex(context.Background(), vcs, org)
+}
+
+func (c *ContextRestClient) contextNameIndex(ctx context.Context, vcs, org string) (map[string]string, error) {
+	contexts, err := c.listAllContexts(ctx, c.ownerParams(vcs, org))
 	if err != nil {
 		return nil, err
 	}
+	index := make(map[string]string, len(contexts))
+	for _, context := range contexts {
+		if _, ok := index[context.Name]; ok {
+			return nil, fmt.Errorf("More than one context is named '%s'", context.Name)
+		}
+		index[context.Name] = context.ID
+	}
+	return index, nil
+}
+
+// A FullContext is a Context along with all of its environment variables.
+type FullContext struct {
+	Context
+	EnvironmentVariables []EnvironmentVariable
+}
 
-	resp, err := c.client.Do(req)
+// GetContextFull finds a single context by its name and returns it along with
+// all of its environment variables. It returns a NotFoundError if the org has
+// no context with that name.
+func (c *ContextRestClient) GetContextFull(vcs, org, name string) (*FullContext, error) {
+	ctx := context.Background()
+	found, err := c.contextByName(ctx, vcs, org, name)
 	if err != nil {
 		return nil, err
 	}
-
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
+	envVars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+		ContextID: &found.ID,
+	})
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
-			return nil, err
+	return &FullContext{Context: *found, EnvironmentVariables: envVars}, nil
+}
+
+func (c *ContextRestClient) contextByName(ctx context.Context, vcs, org, name string) (*Context, error) {
+	params := c.ownerParams(vcs, org)
+	if c.filterContextsByName {
+		params.Name = &name
+	}
+	fetch := reportPages(c.pageCallback, c.contextPages(params))
+	var found *Context
+	_, err := paginate(ctx, nil, 0, func(ctx context.Context, pageToken *string) ([]Context, *string, error) {
+		items, next, err := fetch(ctx, pageToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range items {
+			if items[i].Name == name {
+				// Stop listing once the context has been found.
+				found = &items[i]
+				return nil, nil, nil
+			}
 		}
-		return nil, errors.New(*dest.Message)
+		return nil, next, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Cannot find context named '%s'", name)}
+	}
+	return found, nil
+}
 
+// GetContextByID returns the context with the given ID. It returns a
+// NotFoundError if there is no such context.
+func (c *ContextRestClient) GetContextByID(contextID string) (*Context, error) {
+	req, err := c.newGetContextRequest(contextID)
+	if err != nil {
+		return nil, err
 	}
-	dest := listEnvironmentVariablesResponse{
-		client: c,
-		params: params,
+
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find context with ID '%s'", contextID)}
 	}
-	if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+	var dest Context
+	if _, _, err := c.send(context.Background(), req, notFound, &dest); err != nil {
 		return nil, err
 	}
+	dest.CreatedAt = dest.CreatedAt.UTC()
 	return &dest, nil
 }
 
-func (c *ContextRestClient) listContexts(params *listContextsParams) (*listContextsResponse, error) {
-	req, err := c.newListContextsRequest(params)
+// ContextExists reports whether the org owns a context with the given name.
+// Unlike ContextByName, a missing context is not an error: only failures to
+// find out, such as network or authentication errors, are returned.
+func (c *ContextRestClient) ContextExists(vcs, org, name string) (bool, error) {
+	_, err := c.ContextByName(vcs, org, name)
+	if IsNotFoundError(err) {
+		return false, nil
+	}
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	return true, nil
+}
+
+// ContextByNameRaw is like ContextByName, but also returns the context's JSON
+// exactly as the server sent it. This gives access to fields which Context
+// doesn't model.
+func (c *ContextRestClient) ContextByNameRaw(vcs, org, name string) (*Context, json.RawMessage, error) {
+	fetch := reportPages(c.pageCallback, c.rawContextPages(c.ownerParams(vcs, org)))
+	var found *rawItem[Context]
+	_, err := paginate(context.Background(), nil, 0, func(ctx context.Context, pageToken *string) ([]rawItem[Context], *string, error) {
+		items, next, err := fetch(ctx, pageToken)
+		if err != nil {
+			return nil, nil, err
+		}
+		for i := range items {
+			if items[i].item.Name == name {
+				found = &items[i]
+				return nil, nil, nil
+			}
+		}
+		return nil, next, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if found == nil {
+		return nil, nil, &NotFoundError{Message: fmt.Sprintf("Cannot find context named '%s'", name)}
+	}
+	return &found.item, found.raw, nil
+}
+
+// EnvironmentVariablesRaw is like EnvironmentVariables, but also returns the
+// JSON of each environment variable exactly as the server sent it.
+func (c *ContextRestClient) EnvironmentVariablesRaw(contextID string) (*[]EnvironmentVariable, []json.RawMessage, error) {
+	params := &listEnvironmentVariablesParams{
+		ContextID: &contextID,
+		keepRaw:   true,
+	}
+	items, err := paginate(context.Background(), nil, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]rawItem[EnvironmentVariable], *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listEnvironmentVariables(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return withRaw(resp.Items, resp.rawItems), resp.NextPageToken, nil
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	envVars := make([]EnvironmentVariable, 0, len(items))
+	raw := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		envVars = append(envVars, item.item)
+		raw = append(raw, item.raw)
+	}
+	return &envVars, raw, nil
+}
+
+func (c *ContextRestClient) listAllEnvironmentVariables(ctx context.Context, params *listEnvironmentVariablesParams) ([]EnvironmentVariable, error) {
+	envVars, err := paginate(ctx, params.PageToken, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]EnvironmentVariable, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listEnvironmentVariables(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+	if err == nil && c.sortEnvironmentVariables {
+		sort.SliceStable(envVars, func(i, j int) bool {
+			return envVars[i].CreatedAt.Before(envVars[j].CreatedAt)
+		})
+	}
+	return envVars, err
+}
+
+func (c *ContextRestClient) listAllContexts(ctx context.Context, params *listContextsParams) ([]Context, error) {
+	return paginate(ctx, params.PageToken, 0, reportPages(c.pageCallback, c.contextPages(params)))
+}
+
+func (c *ContextRestClient) contextPages(params *listContextsParams) pageFetcher[Context] {
+	return func(ctx context.Context, pageToken *string) ([]Context, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listContexts(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
 	}
+}
 
-	resp, err := c.client.Do(req)
+// rawContextPages is like contextPages, but keeps the raw JSON of each
+// context.
+func (c *ContextRestClient) rawContextPages(params *listContextsParams) pageFetcher[rawItem[Context]] {
+	params.keepRaw = true
+	return func(ctx context.Context, pageToken *string) ([]rawItem[Context], *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listContexts(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return withRaw(resp.Items, resp.rawItems), resp.NextPageToken, nil
+	}
+}
+
+func (c *ContextRestClient) listEnvironmentVariables(ctx context.Context, params *listEnvironmentVariablesParams) (*listEnvironmentVariablesResponse, error) {
+	req, err := c.newListEnvironmentVariablesRequest(params)
 	if err != nil {
 		return nil, err
 	}
 
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
+	dest := listEnvironmentVariablesResponse{
+		client: c,
+		params: params,
+	}
+	resp, bodyBytes, err := c.send(ctx, req, nil, &dest)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode != 200 {
-		var dest errorResponse
-		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
+		return nil, err
+	}
+	if params.keepRaw {
+		var raw rawItemsResponse
+		if err := json.Unmarshal(bodyBytes, &raw); err != nil {
 			return nil, err
-
 		}
-		return nil, errors.New(*dest.Message)
+		dest.rawItems = raw.Items
+	}
+	return &dest, nil
+}
 
+func (c *ContextRestClient) listContexts(ctx context.Context, params *listContextsParams) (*listContextsResponse, error) {
+	req, err := c.newListContextsRequest(params)
+	if err != nil {
+		return nil, err
 	}
 
 	dest := listContextsResponse{
 		client: c,
 		params: params,
 	}
-	if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+	resp, bodyBytes, err := c.send(ctx, req, nil, &dest)
+	if err != nil {
+		return nil, err
+	}
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
 		return nil, err
 	}
+	for i := range dest.Items {
+		dest.Items[i].CreatedAt = dest.Items[i].CreatedAt.UTC()
+	}
+	if params.keepRaw {
+		var raw rawItemsResponse
+		if err := json.Unmarshal(bodyBytes, &raw); err != nil {
+			return nil, err
+		}
+		dest.rawItems = raw.Items
+	}
 	return &dest, nil
 }
 
@@ -338,30 +944,24 @@ func (c *ContextRestClient) newCreateContextRequest(vcs, org, name string) (*htt
 		return nil, err
 	}
 
-	var bodyReader io.Reader
-
+	owner := c.ownerParams(vcs, org)
 	var body = struct {
 		Name  string `json:"name"`
 		Owner struct {
+			ID   *string `json:"id,omitempty"`
 			Slug *string `json:"slug,omitempty"`
 		} `json:"owner"`
 	}{
 		Name: name,
 		Owner: struct {
+			ID   *string `json:"id,omitempty"`
 			Slug *string `json:"slug,omitempty"`
 		}{
-			Slug: toSlug(vcs, org),
+			ID:   owner.OwnerID,
+			Slug: owner.OwnerSlug,
 		},
 	}
-	buf, err := json.Marshal(body)
-
-	if err != nil {
-		return nil, err
-	}
-
-	bodyReader = bytes.NewReader(buf)
-
-	return c.newHTTPRequest("POST", queryURL.String(), bodyReader)
+	return c.newJSONRequest("POST", queryURL.String(), body)
 }
 
 func (c *ContextRestClient) newCreateEnvironmentVariableRequest(contextID, variable, value string) (*http.Request, error) {
@@ -375,34 +975,38 @@ func (c *ContextRestClient) newCreateEnvironmentVariableRequest(contextID, varia
 		return nil, err
 	}
 
-	var bodyReader io.Reader
 	body := struct {
 		Value string `json:"value"`
 	}{
 		Value: value,
 	}
-	buf, err := json.Marshal(body)
+	return c.newJSONRequest("PUT", queryURL.String(), body)
+}
 
+func (c *ContextRestClient) newDeleteEnvironmentVariableRequest(contextID, name string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
 	if err != nil {
 		return nil, err
 	}
-
-	bodyReader = bytes.NewReader(buf)
-
-	return c.newHTTPRequest("PUT", queryURL.String(), bodyReader)
+	queryURL, err = queryURL.Parse(fmt.Sprintf("context/%s/environment-variable/%s", contextID, name))
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("DELETE", queryURL.String(), nil)
 }
 
-func (c *ContextRestClient) newDeleteEnvironmentVariableRequest(contextID, name string) (*http.Request, error) {
+func (c *ContextRestClient) newGetContextRequest(contextID string) (*http.Request, error) {
 	var err error
 	queryURL, err := url.Parse(c.server)
 	if err != nil {
 		return nil, err
 	}
-	queryURL, err = queryURL.Parse(fmt.Sprintf("context/%s/environment-variable/%s", contextID, name))
+	queryURL, err = queryURL.Parse(fmt.Sprintf("context/%s", contextID))
 	if err != nil {
 		return nil, err
 	}
-	return c.newHTTPRequest("DELETE", queryURL.String(), nil)
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
 }
 
 func (c *ContextRestClient) newDeleteContextRequest(contextID string) (*http.Request, error) {
@@ -419,6 +1023,9 @@ func (c *ContextRestClient) newDeleteContextRequest(contextID string) (*http.Req
 }
 
 func (c *ContextRestClient) newListEnvironmentVariablesRequest(params *listEnvironmentVariablesParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
 	var err error
 	queryURL, err := url.Parse(c.server)
 	if err != nil {
@@ -434,10 +1041,13 @@ func (c *ContextRestClient) newListEnvironmentVariablesRequest(params *listEnvir
 	}
 	queryURL.RawQuery = urlParams.Encode()
 
-	return c.newHTTPRequest("GET", queryURL.String(), nil)
+	return c.newListRequest(queryURL.String())
 }
 
 func (c *ContextRestClient) newListContextsRequest(params *listContextsParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
 	var err error
 	queryURL, err := url.Parse(c.server)
 	if err != nil {
@@ -461,19 +1071,131 @@ func (c *ContextRestClient) newListContextsRequest(params *listContextsParams) (
 	if params.PageToken != nil {
 		urlParams.Add("page-token", *params.PageToken)
 	}
+	if params.Name != nil {
+		urlParams.Add("name", *params.Name)
+	}
 
 	queryURL.RawQuery = urlParams.Encode()
 
-	return c.newHTTPRequest("GET", queryURL.String(), nil)
+	return c.newListRequest(queryURL.String())
+}
+
+// send sends req with ctx and reads the response, which it returns along with
+// its body. Every method calling the API goes through send, other than those
+// which stream the response body, such as DownloadArtifact.
+//
+// A response is successful if its status is 2xx. A 404 fails with the error
+// returned by notFound, if it isn't nil, and any other unsuccessful status
+// with an APIError. If dest isn't nil, the body of a successful response is
+// decoded into it; an empty body fails with ErrEmptyResponseBody, and a
+// resource missing a required field fails as described by checkResource. The
+// response is returned alongside the errors of unsuccessful statuses and of
+// decoding, but not of sending or reading. For clients configured
+// WithConditionalRequests, a 304 is first replaced by the remembered response.
+func (c *ContextRestClient) send(ctx context.Context, req *http.Request, notFound func() error, dest interface{}) (*http.Response, []byte, error) {
+	cached, isCached := c.conditionalRequest(req)
+	resp, err := c.do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bodyBytes, err := c.readBody(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	bodyBytes = c.conditionalResponse(req, resp, bodyBytes, cached, isCached)
+	if resp.StatusCode == http.StatusNotFound && notFound != nil {
+		return resp, bodyBytes, notFound()
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp, bodyBytes, newAPIError(resp, bodyBytes)
+	}
+	if dest != nil {
+		if err := c.decodeBody(resp.StatusCode, bodyBytes, dest); err != nil {
+			return resp, bodyBytes, err
+		}
+		if err := checkResource(resp, bodyBytes, dest); err != nil {
+			return resp, bodyBytes, err
+		}
+	}
+	return resp, bodyBytes, nil
+}
+
+// readBody reads and closes the body of resp, giving up if it takes longer
+// than the configured body read timeout, and decompresses it as described by
+// decompressBody.
+func (c *ContextRestClient) readBody(resp *http.Response) ([]byte, error) {
+	bodyBytes, err := c.readRawBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	return decompressBody(resp, bodyBytes)
+}
+
+func (c *ContextRestClient) readRawBody(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+	if c.bodyReadTimeout <= 0 {
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	var timedOut int32
+	timer := time.AfterFunc(c.bodyReadTimeout, func() {
+		atomic.StoreInt32(&timedOut, 1)
+		resp.Body.Close()
+	})
+	defer timer.Stop()
+
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	if atomic.LoadInt32(&timedOut) == 1 {
+		return nil, fmt.Errorf("Timed out reading the response body after %s", c.bodyReadTimeout)
+	}
+	return bodyBytes, err
+}
+
+// decodeBody decodes the JSON body of a successful response into dest,
+// strictly if the client was configured WithStrictDecoding.
+func (c *ContextRestClient) decodeBody(statusCode int, bodyBytes []byte, dest interface{}) error {
+	return decodeBody(statusCode, bodyBytes, dest, c.strictDecoding)
+}
+
+func decodeBody(statusCode int, bodyBytes []byte, dest interface{}, strict bool) error {
+	if len(bytes.TrimSpace(bodyBytes)) == 0 {
+		return ErrEmptyResponseBody
+	}
+	decoder := json.NewDecoder(bytes.NewReader(bodyBytes))
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(dest); err != nil {
+		return newDecodeError(statusCode, bodyBytes, err)
+	}
+	return nil
+}
+
+func (c *ContextRestClient) newListRequest(url string) (*http.Request, error) {
+	req, err := c.newHTTPRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.minimalResponses {
+		req.Header.Add("Prefer", "return=minimal")
+	}
+	return req, nil
 }
 
 func (c *ContextRestClient) newHTTPRequest(method, url string, body io.Reader) (*http.Request, error) {
+	body, compressed, err := c.compressBody(body)
+	if err != nil {
+		return nil, err
+	}
 	req, err := http.NewRequest(method, url, body)
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Add("circle-token", c.token)
-	req.Header.Add("Accept", "application/json")
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	req.Header.Add("Accept", c.acceptHeader)
 	req.Header.Add("Content-Type", "application/json")
 	return req, nil
 }
@@ -481,29 +1203,15 @@ func (c *ContextRestClient) newHTTPRequest(method, url string, body io.Reader) (
 // EnsureExists verifies that the REST API exists and has the necessary
 // endpoints to interact with contexts and env vars.
 func (c *ContextRestClient) EnsureExists() error {
-	queryURL, err := url.Parse(c.server)
-	if err != nil {
-		return err
-	}
-	queryURL, err = queryURL.Parse("openapi.json")
-	if err != nil {
-		return err
-	}
-	req, err := c.newHTTPRequest("GET", queryURL.String(), nil)
+	req, err := c.newOpenAPIRequest()
 	if err != nil {
 		return err
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return err
-	}
-	if resp.StatusCode != 200 {
+	resp, bodyBytes, err := c.send(context.Background(), req, nil, nil)
+	if err != nil && resp != nil {
 		return errors.New("API v2 test request failed.")
 	}
-
-	bodyBytes, err := ioutil.ReadAll(resp.Body)
-	defer resp.Body.Close()
 	if err != nil {
 		return err
 	}
@@ -512,7 +1220,9 @@ func (c *ContextRestClient) EnsureExists() error {
 			ContextEndpoint interface{} `json:"/context"`
 		}
 	}
-	if err := json.Unmarshal(bodyBytes, &respBody); err != nil {
+	// The OpenAPI document is far larger than respBody, so it is never decoded
+	// strictly.
+	if err := decodeBody(resp.StatusCode, bodyBytes, &respBody, false); err != nil {
 		return err
 	}
 
@@ -523,14 +1233,34 @@ func (c *ContextRestClient) EnsureExists() error {
 	return nil
 }
 
+var apiPathPattern = regexp.MustCompile(`/api/v\d.*$`)
+
+// normalizeHost cleans up a host which was copy-pasted from a full API URL,
+// such as https://circleci.com/api/v2/context?owner-slug=gh/org, by dropping
+// the query string, the fragment and anything from an /api/vN path onwards.
+// Any other base path is left as is.
+func normalizeHost(host string) (*url.URL, error) {
+	hostURL, err := url.Parse(host)
+	if err != nil {
+		return nil, err
+	}
+	hostURL.RawQuery = ""
+	hostURL.Fragment = ""
+	// Keep the slash before /api/vN, so that the endpoint is resolved below
+	// any base path rather than replacing its last segment.
+	hostURL.Path = apiPathPattern.ReplaceAllString(hostURL.Path, "/")
+	hostURL.RawPath = ""
+	return hostURL, nil
+}
+
 // NewContextRestClient returns a new client satisfying the api.ContextInterface
 // interface via the REST API.
-func NewContextRestClient(config settings.Config) (*ContextRestClient, error) {
+func NewContextRestClient(config settings.Config, opts ...ContextRestOption) (*ContextRestClient, error) {
 	// Ensure server ends with a slash
 	if !strings.HasSuffix(config.Endpoint, "/") {
 		config.Endpoint += "/"
 	}
-	serverURL, err := url.Parse(config.Host)
+	serverURL, err := normalizeHost(config.Host)
 	if err != nil {
 		return nil, err
 	}
@@ -541,10 +1271,40 @@ func NewContextRestClient(config settings.Config) (*ContextRestClient, error) {
 	}
 
 	client := &ContextRestClient{
-		token:  config.Token,
+		token:  StaticToken(config.Token),
 		server: serverURL.String(),
 		client: config.HTTPClient,
+
+		validateParameter: ValidatePipelineParameter,
+		acceptHeader:      defaultAcceptHeader,
+		encode:            marshalJSON,
+		buildSlug:         defaultSlug,
+
+		maxAttempts:    1,
+		retryBaseDelay: defaultRetryBaseDelay,
+		retryMaxDelay:  defaultRetryMaxDelay,
+		jitter:         &lockedRand{rand: rand.New(rand.NewSource(time.Now().UnixNano()))},
+		after:          time.After,
+		rateLimit:      &rateLimitTracker{},
+		lastRequestID:  &requestIDTracker{},
+		ownerIDs:       &ownerIDCache{},
+
+		minTLSVersion: tls.VersionTLS12,
+	}
+
+	for _, opt := range opts {
+		opt(client)
+	}
+	client.clientCtx, client.cancelAll = context.WithCancel(context.Background())
+	if client.client == nil {
+		client.client, err = client.newHTTPClient()
+	} else if !client.customClient {
+		client.client, err = client.tuneHTTPClient(client.client)
+	}
+	if err != nil {
+		return nil, err
 	}
+	client.client = withRedirectPolicy(client.client)
 
 	return client, nil
 }
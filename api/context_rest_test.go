@@ -0,0 +1,1190 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeContextAPI is an in-memory implementation of the context endpoints of
+// the CircleCI REST API, serving pageSize items per page.
+type fakeContextAPI struct {
+	mu       sync.Mutex
+	pageSize int
+	nextID   int
+	contexts []Context
+	envVars  map[string][]EnvironmentVariable
+	values   map[string]string // keyed by "contextID/variable"
+	requests []string
+
+	// fail, if set, is consulted before each request is handled. Returning a
+	// non-zero status code makes the request fail with that code.
+	fail func(req *http.Request) int
+}
+
+func newFakeContextAPI() *fakeContextAPI {
+	return &fakeContextAPI{
+		pageSize: 2,
+		envVars:  map[string][]EnvironmentVariable{},
+		values:   map[string]string{},
+	}
+}
+
+func (f *fakeContextAPI) addContext(name string, createdAt time.Time, variables ...string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	id := fmt.Sprintf("context-%d", f.nextID)
+	f.contexts = append(f.contexts, Context{ID: id, Name: name, CreatedAt: createdAt})
+	for _, variable := range variables {
+		f.envVars[id] = append(f.envVars[id], EnvironmentVariable{Variable: variable, ContextID: id, CreatedAt: createdAt})
+	}
+	return id
+}
+
+func (f *fakeContextAPI) Requests() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.requests...)
+}
+
+func (f *fakeContextAPI) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req.Method+" "+req.URL.Path)
+	fail := f.fail
+	f.mu.Unlock()
+
+	if fail != nil {
+		if code := fail(req); code != 0 {
+			rw.WriteHeader(code)
+			_, _ = fmt.Fprintf(rw, `{"message": "%s"}`, http.StatusText(code))
+			return
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.Split(strings.TrimPrefix(req.URL.Path, "/api/v2/"), "/")
+	switch {
+	case len(path) == 1 && path[0] == "context" && req.Method == "GET":
+		items := make([]interface{}, len(f.contexts))
+		for i, c := range f.contexts {
+			items[i] = c
+		}
+		f.writePage(rw, req, items)
+	case len(path) == 1 && path[0] == "context" && req.Method == "POST":
+		var body struct {
+			Name string `json:"name"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		f.nextID++
+		context := Context{ID: fmt.Sprintf("context-%d", f.nextID), Name: body.Name, CreatedAt: time.Now()}
+		f.contexts = append(f.contexts, context)
+		_ = json.NewEncoder(rw).Encode(context)
+	case len(path) == 2 && path[0] == "context" && req.Method == "GET":
+		for _, c := range f.contexts {
+			if c.ID == path[1] {
+				_ = json.NewEncoder(rw).Encode(c)
+				return
+			}
+		}
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte(`{"message": "Context not found."}`))
+	case len(path) == 2 && path[0] == "context" && req.Method == "DELETE":
+		f.deleteContext(path[1])
+		_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+	case len(path) == 3 && path[0] == "context" && path[2] == "environment-variable" && req.Method == "GET":
+		vars := f.envVars[path[1]]
+		items := make([]interface{}, len(vars))
+		for i, v := range vars {
+			items[i] = map[string]interface{}{
+				"variable":   v.Variable,
+				"context_id": v.ContextID,
+				"created_at": v.CreatedAt,
+			}
+		}
+		f.writePage(rw, req, items)
+	case len(path) == 4 && path[0] == "context" && path[2] == "environment-variable" && req.Method == "PUT":
+		var body struct {
+			Value string `json:"value"`
+		}
+		_ = json.NewDecoder(req.Body).Decode(&body)
+		f.values[path[1]+"/"+path[3]] = body.Value
+		v := EnvironmentVariable{Variable: path[3], ContextID: path[1], CreatedAt: time.Now()}
+		f.deleteEnvVar(path[1], path[3])
+		f.envVars[path[1]] = append(f.envVars[path[1]], v)
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"variable":   v.Variable,
+			"context_id": v.ContextID,
+			"created_at": v.CreatedAt,
+		})
+	case len(path) == 4 && path[0] == "context" && path[2] == "environment-variable" && req.Method == "DELETE":
+		f.deleteEnvVar(path[1], path[3])
+		_, _ = rw.Write([]byte(`{"message": "Environment variable deleted."}`))
+	default:
+		rw.WriteHeader(http.StatusNotFound)
+		_, _ = rw.Write([]byte(`{"message": "Not found."}`))
+	}
+}
+
+func (f *fakeContextAPI) writePage(rw http.ResponseWriter, req *http.Request, items []interface{}) {
+	start := 0
+	if token := req.URL.Query().Get("page-token"); token != "" {
+		start, _ = strconv.Atoi(token)
+	}
+	end := start + f.pageSize
+	if end > len(items) {
+		end = len(items)
+	}
+	var next *string
+	if end < len(items) {
+		token := strconv.Itoa(end)
+		next = &token
+	}
+	page := items[start:end]
+	if page == nil {
+		page = []interface{}{}
+	}
+	_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+		"items":           page,
+		"next_page_token": next,
+	})
+}
+
+func (f *fakeContextAPI) deleteContext(id string) {
+	for i, c := range f.contexts {
+		if c.ID == id {
+			f.contexts = append(f.contexts[:i], f.contexts[i+1:]...)
+			break
+		}
+	}
+	delete(f.envVars, id)
+}
+
+func (f *fakeContextAPI) deleteEnvVar(contextID, variable string) {
+	vars := f.envVars[contextID]
+	for i, v := range vars {
+		if v.Variable == variable {
+			f.envVars[contextID] = append(vars[:i], vars[i+1:]...)
+			return
+		}
+	}
+}
+
+func newFakeContextServer(opts ...ContextRestOption) (*fakeContextAPI, *httptest.Server, *ContextRestClient) {
+	fake := newFakeContextAPI()
+	server := httptest.NewServer(fake)
+	return fake, server, newTestRestClient(server, opts...)
+}
+
+var _ = ginkgo.Describe("Context REST client", func() {
+	ginkgo.Describe("ListContextsForAllOwnerTypes", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			byOwnerType := map[string][]Context{
+				"organization": {{ID: "shared", Name: "shared"}, {ID: "org-only", Name: "org-only"}},
+				"account":      {{ID: "shared", Name: "shared"}, {ID: "account-only", Name: "account-only"}},
+			}
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.URL.Query().Get("owner-slug")).To(Equal("gh/test-org"))
+				items, ok := byOwnerType[req.URL.Query().Get("owner-type")]
+				Expect(ok).To(BeTrue())
+				Expect(json.NewEncoder(rw).Encode(map[string]interface{}{"items": items})).To(Succeed())
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("merges both owner types, deduplicating by ID", func() {
+			client := newTestRestClient(server)
+			contexts, err := client.ListContextsForAllOwnerTypes("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+
+			var ids []string
+			for _, context := range *contexts {
+				ids = append(ids, context.ID)
+			}
+			Expect(ids).To(Equal([]string{"shared", "org-only", "account-only"}))
+		})
+	})
+
+	ginkgo.Describe("ExportContextVariableNames", func() {
+		ginkgo.It("returns the variable names sorted alphabetically", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now(), "ZED", "ALPHA", "MIDDLE")
+
+			names, err := client.ExportContextVariableNames(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"ALPHA", "MIDDLE", "ZED"}))
+		})
+	})
+
+	ginkgo.Describe("EnvironmentVariableCount", func() {
+		ginkgo.It("counts the variables across pages", func() {
+			var pages []int
+			fake, server, client := newFakeContextServer(WithPageCallback(func(page, items int) {
+				pages = append(pages, items)
+			}))
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now(), "ALPHA", "BETA", "GAMMA")
+
+			count, err := client.EnvironmentVariableCount(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(count).To(Equal(3))
+			Expect(pages).To(Equal([]int{2, 1}))
+		})
+	})
+
+	ginkgo.Describe("raw JSON variants", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				switch {
+				case strings.HasSuffix(req.URL.Path, "/environment-variable") && req.URL.Query().Get("page-token") == "":
+					_, _ = rw.Write([]byte(`{"items": [{"variable": "FOO", "extra": 1}], "next_page_token": "2"}`))
+				case strings.HasSuffix(req.URL.Path, "/environment-variable"):
+					_, _ = rw.Write([]byte(`{"items": [{"variable": "BAR", "extra": 2}]}`))
+				default:
+					_, _ = rw.Write([]byte(`{"items": [{"id": "1", "name": "other"}, {"id": "2", "name": "ctx", "restricted": true}]}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the context's JSON as sent by the server", func() {
+			client := newTestRestClient(server)
+			context, raw, err := client.ContextByNameRaw("gh", "test-org", "ctx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.ID).To(Equal("2"))
+			Expect(string(raw)).To(Equal(`{"id": "2", "name": "ctx", "restricted": true}`))
+		})
+
+		ginkgo.It("returns each environment variable's JSON as sent by the server", func() {
+			client := newTestRestClient(server)
+			envVars, raw, err := client.EnvironmentVariablesRaw("context-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*envVars).To(HaveLen(2))
+			Expect(raw).To(HaveLen(2))
+			Expect(string(raw[0])).To(Equal(`{"variable": "FOO", "extra": 1}`))
+			Expect(string(raw[1])).To(Equal(`{"variable": "BAR", "extra": 2}`))
+		})
+	})
+
+	ginkgo.Describe("ListContexts", func() {
+		ginkgo.It("filters contexts by creation time", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			checkpoint := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+			fake.addContext("old", checkpoint.Add(-time.Hour))
+			fake.addContext("exact", checkpoint)
+			fake.addContext("new", checkpoint.Add(time.Hour))
+			fake.addContext("older", checkpoint.Add(-48*time.Hour))
+			fake.addContext("newer", checkpoint.Add(48*time.Hour))
+
+			contexts, err := client.ListContexts("gh", "test-org", WithCreatedAfter(checkpoint))
+			Expect(err).ToNot(HaveOccurred())
+			var names []string
+			for _, context := range *contexts {
+				names = append(names, context.Name)
+			}
+			Expect(names).To(Equal([]string{"new", "newer"}))
+
+			all, err := client.ListContexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*all).To(HaveLen(5))
+		})
+	})
+
+	ginkgo.Describe("PutEnvironmentVariable", func() {
+		ginkgo.It("returns the stored variable without its value", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now())
+
+			envVar, err := client.PutEnvironmentVariable(id, "FOO", "super-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envVar.Variable).To(Equal("FOO"))
+			Expect(envVar.ContextID).To(Equal(id))
+			Expect(envVar.CreatedAt).ToNot(BeZero())
+
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect((*envVars)[0].ContextID).To(Equal(id))
+			Expect((*envVars)[0].CreatedAt).To(BeTemporally("~", envVar.CreatedAt, time.Second))
+		})
+
+		ginkgo.It("decodes the GraphQL API's keys too", func() {
+			var envVar EnvironmentVariable
+			Expect(json.Unmarshal([]byte(`{"variable": "FOO", "createdAt": "2021-06-01T00:00:00Z"}`), &envVar)).To(Succeed())
+			Expect(envVar.Variable).To(Equal("FOO"))
+			Expect(envVar.CreatedAt).To(Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+
+	ginkgo.Describe("WithStrictDecoding", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				_, _ = rw.Write([]byte(`{"items": [{"id": "1", "name": "ctx", "restricted": true}]}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("tolerates unknown fields by default", func() {
+			client := newTestRestClient(server)
+			contexts, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect((*contexts)[0].Name).To(Equal("ctx"))
+		})
+
+		ginkgo.It("rejects unknown fields when strict", func() {
+			client := newTestRestClient(server, WithStrictDecoding())
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).To(BeAssignableToTypeOf(&DecodeError{}))
+			Expect(err).To(MatchError(ContainSubstring(`unknown field "restricted"`)))
+		})
+	})
+
+	ginkgo.Describe("WithPageCallback", func() {
+		ginkgo.It("is called once per page", func() {
+			var pages [][2]int
+			fake, server, client := newFakeContextServer(WithPageCallback(func(page, items int) {
+				pages = append(pages, [2]int{page, items})
+			}))
+			defer server.Close()
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				fake.addContext(name, time.Now())
+			}
+
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pages).To(Equal([][2]int{{1, 2}, {2, 2}, {3, 1}}))
+
+			pages = nil
+			_, err = client.EnvironmentVariables("context-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pages).To(Equal([][2]int{{1, 0}}))
+
+			pages = nil
+			_, err = client.ListContexts("gh", "test-org", WithCreatedAfter(time.Time{}))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pages).To(Equal([][2]int{{1, 2}, {2, 2}, {3, 1}}))
+
+			pages = nil
+			_, err = client.ContextByName("gh", "test-org", "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pages).To(Equal([][2]int{{1, 2}, {2, 2}}))
+
+			pages = nil
+			_, _, err = client.EnvironmentVariablesRaw("context-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pages).To(Equal([][2]int{{1, 0}}))
+		})
+	})
+
+	ginkgo.Describe("ForEachContext", func() {
+		ginkgo.It("calls the callback with every context across pages", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				fake.addContext(name, time.Now())
+			}
+
+			var names []string
+			err := client.ForEachContext("gh", "test-org", func(context Context) error {
+				names = append(names, context.Name)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(names).To(Equal([]string{"a", "b", "c", "d", "e"}))
+		})
+
+		ginkgo.It("stops at the first error from the callback", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			for _, name := range []string{"a", "b", "c"} {
+				fake.addContext(name, time.Now())
+			}
+
+			var names []string
+			err := client.ForEachContext("gh", "test-org", func(context Context) error {
+				names = append(names, context.Name)
+				return fmt.Errorf("stop at %s", context.Name)
+			})
+			Expect(err).To(MatchError("stop at a"))
+			Expect(names).To(Equal([]string{"a"}))
+		})
+	})
+
+	ginkgo.Describe("ContextNameIndex", func() {
+		ginkgo.It("maps every context's name to its ID", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			expected := map[string]string{}
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				expected[name] = fake.addContext(name, time.Now())
+			}
+
+			index, err := client.ContextNameIndex("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(index).To(Equal(expected))
+			Expect(fake.Requests()).To(HaveLen(3))
+		})
+
+		ginkgo.It("rejects duplicate names", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			fake.addContext("dup", time.Now())
+			fake.addContext("dup", time.Now())
+
+			_, err := client.ContextNameIndex("gh", "test-org")
+			Expect(err).To(MatchError("More than one context is named 'dup'"))
+		})
+	})
+
+	ginkgo.Describe("GetContextFull", func() {
+		var (
+			fake   *fakeContextAPI
+			server *httptest.Server
+			client *ContextRestClient
+		)
+
+		ginkgo.BeforeEach(func() {
+			fake, server, client = newFakeContextServer()
+			fake.addContext("other", time.Now(), "OTHER")
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the context with its variables", func() {
+			id := fake.addContext("ctx", time.Now(), "A", "B", "C")
+			full, err := client.GetContextFull("gh", "test-org", "ctx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(full.ID).To(Equal(id))
+			Expect(full.Name).To(Equal("ctx"))
+			var names []string
+			for _, envVar := range full.EnvironmentVariables {
+				Expect(envVar.ContextID).To(Equal(id))
+				names = append(names, envVar.Variable)
+			}
+			Expect(names).To(Equal([]string{"A", "B", "C"}))
+		})
+
+		ginkgo.It("returns a NotFoundError without listing any variables", func() {
+			_, err := client.GetContextFull("gh", "test-org", "missing")
+			Expect(IsNotFoundError(err)).To(BeTrue())
+			Expect(err).To(MatchError("Cannot find context named 'missing'"))
+			for _, request := range fake.Requests() {
+				Expect(request).To(Equal("GET /api/v2/context"))
+			}
+		})
+	})
+
+	ginkgo.Describe("GetContextByID", func() {
+		ginkgo.It("returns the context", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC))
+
+			context, err := client.GetContextByID(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.Name).To(Equal("ctx"))
+			Expect(context.CreatedAt).To(Equal(time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)))
+			Expect(context.CreatedBy).To(BeNil())
+		})
+
+		ginkgo.It("returns a NotFoundError for unknown IDs", func() {
+			_, server, client := newFakeContextServer()
+			defer server.Close()
+			_, err := client.GetContextByID("missing")
+			Expect(IsNotFoundError(err)).To(BeTrue())
+			Expect(err).To(MatchError("Cannot find context with ID 'missing'"))
+		})
+
+		ginkgo.It("includes the creator when the API reports one", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				_, _ = rw.Write([]byte(`{
+					"id": "context-id",
+					"name": "ctx",
+					"created_at": "2021-06-01T00:00:00Z",
+					"created_by": {"id": "user-id", "login": "octocat"}
+				}`))
+			}))
+			defer server.Close()
+
+			context, err := newTestRestClient(server).GetContextByID("context-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.CreatedBy).To(Equal(&ContextCreator{ID: "user-id", Login: "octocat"}))
+		})
+	})
+
+	ginkgo.Describe("creation times", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				context := `{"id": "context-id", "name": "ctx", "created_at": "2021-06-01T05:00:00+05:00"}`
+				switch {
+				case strings.HasSuffix(req.URL.Path, "/environment-variable"):
+					_, _ = rw.Write([]byte(`{"items": [{"variable": "A", "context_id": "context-id", "created_at": "2021-06-01T05:00:00+05:00"}]}`))
+				case strings.HasSuffix(req.URL.Path, "/context"):
+					_, _ = rw.Write([]byte(`{"items": [` + context + `]}`))
+				default:
+					_, _ = rw.Write([]byte(context))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		expected := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		ginkgo.It("normalizes a fetched context to UTC", func() {
+			context, err := newTestRestClient(server).GetContextByID("context-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.CreatedAt.Location()).To(Equal(time.UTC))
+			Expect(context.CreatedAt).To(Equal(expected))
+		})
+
+		ginkgo.It("normalizes listed contexts to UTC", func() {
+			contexts, err := newTestRestClient(server).Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(1))
+			Expect((*contexts)[0].CreatedAt.Location()).To(Equal(time.UTC))
+			Expect((*contexts)[0].CreatedAt).To(Equal(expected))
+		})
+
+		ginkgo.It("normalizes environment variables to UTC", func() {
+			variables, err := newTestRestClient(server).EnvironmentVariables("context-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*variables).To(HaveLen(1))
+			Expect((*variables)[0].CreatedAt.Location()).To(Equal(time.UTC))
+			Expect((*variables)[0].CreatedAt).To(Equal(expected))
+		})
+	})
+
+	ginkgo.Describe("ContextExists", func() {
+		var (
+			fake   *fakeContextAPI
+			server *httptest.Server
+			client *ContextRestClient
+		)
+
+		ginkgo.BeforeEach(func() {
+			fake, server, client = newFakeContextServer()
+			for _, name := range []string{"a", "b", "c"} {
+				fake.addContext(name, time.Now())
+			}
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("reports contexts which exist", func() {
+			exists, err := client.ContextExists("gh", "test-org", "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeTrue())
+		})
+
+		ginkgo.It("reports contexts which don't exist without an error", func() {
+			exists, err := client.ContextExists("gh", "test-org", "missing")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exists).To(BeFalse())
+		})
+
+		ginkgo.It("returns errors other than not found", func() {
+			fake.fail = func(req *http.Request) int {
+				return http.StatusUnauthorized
+			}
+			exists, err := client.ContextExists("gh", "test-org", "a")
+			Expect(err).To(MatchError("Unauthorized"))
+			Expect(exists).To(BeFalse())
+		})
+	})
+
+	ginkgo.Describe("RotateEnvironmentVariable", func() {
+		ginkgo.It("overwrites the value and confirms the variable is stored", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now(), "TOKEN")
+			fake.values[id+"/TOKEN"] = "old-secret"
+
+			envVar, err := client.RotateEnvironmentVariable(id, "TOKEN", "new-secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(envVar.Variable).To(Equal("TOKEN"))
+			Expect(envVar.ContextID).To(Equal(id))
+			Expect(fake.values[id+"/TOKEN"]).To(Equal("new-secret"))
+			Expect(fake.Requests()).To(Equal([]string{
+				"PUT /api/v2/context/" + id + "/environment-variable/TOKEN",
+				"GET /api/v2/context/" + id + "/environment-variable",
+			}))
+		})
+
+		ginkgo.It("fails if the variable can't be read back", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method == "PUT" {
+					_, _ = rw.Write([]byte(`{"variable": "TOKEN"}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			}))
+			defer server.Close()
+			client := newTestRestClient(server)
+
+			_, err := client.RotateEnvironmentVariable("context-id", "TOKEN", "new-secret")
+			Expect(err).To(MatchError("Environment variable 'TOKEN' is missing from context context-id after rotating it"))
+		})
+	})
+
+	ginkgo.Describe("SyncContexts", func() {
+		ginkgo.It("returns only the contexts created since the last sync", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			checkpoint := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+			fake.addContext("old", checkpoint.Add(-time.Hour))
+			fake.addContext("newest", checkpoint.Add(2*time.Hour))
+			fake.addContext("new", checkpoint.Add(time.Hour))
+
+			contexts, next, err := client.SyncContexts("gh", "test-org", checkpoint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(2))
+			Expect(next).To(Equal(checkpoint.Add(2 * time.Hour)))
+
+			contexts, again, err := client.SyncContexts("gh", "test-org", next)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(BeEmpty())
+			Expect(again).To(Equal(next))
+
+			fake.addContext("added", checkpoint.Add(3*time.Hour))
+			contexts, _, err = client.SyncContexts("gh", "test-org", next)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(1))
+			Expect((*contexts)[0].Name).To(Equal("added"))
+		})
+	})
+
+	ginkgo.Describe("ContextsFrom", func() {
+		var (
+			fake   *fakeContextAPI
+			server *httptest.Server
+			client *ContextRestClient
+		)
+
+		ginkgo.BeforeEach(func() {
+			fake, server, client = newFakeContextServer()
+			for _, name := range []string{"a", "b", "c", "d", "e"} {
+				fake.addContext(name, time.Now())
+			}
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("resumes listing from the given page", func() {
+			contexts, next, err := client.ContextsFrom("gh", "test-org", "2")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(next).To(BeEmpty())
+			var names []string
+			for _, context := range *contexts {
+				names = append(names, context.Name)
+			}
+			Expect(names).To(Equal([]string{"c", "d", "e"}))
+		})
+
+		ginkgo.It("returns the token to resume from when a page fails", func() {
+			fake.fail = func(req *http.Request) int {
+				if req.URL.Query().Get("page-token") == "4" {
+					return http.StatusInternalServerError
+				}
+				return 0
+			}
+			contexts, next, err := client.ContextsFrom("gh", "test-org", "2")
+			Expect(err).To(HaveOccurred())
+			Expect(*contexts).To(HaveLen(2))
+			Expect(next).To(Equal("4"))
+		})
+
+		ginkgo.It("requires a page token", func() {
+			_, _, err := client.ContextsFrom("gh", "test-org", "")
+			Expect(err).To(MatchError("A page token is required to resume listing contexts"))
+		})
+	})
+
+	ginkgo.Describe("WithServerSideNameFilter", func() {
+		// namesSent records the name query parameter of each listing request.
+		namesSent := func(fake *fakeContextAPI) *[]string {
+			var names []string
+			fake.fail = func(req *http.Request) int {
+				if values, ok := req.URL.Query()["name"]; ok {
+					names = append(names, values...)
+				}
+				return 0
+			}
+			return &names
+		}
+
+		ginkgo.It("sends the name to the server", func() {
+			fake, server, client := newFakeContextServer(WithServerSideNameFilter())
+			defer server.Close()
+			for _, name := range []string{"a", "b", "c"} {
+				fake.addContext(name, time.Now())
+			}
+			names := namesSent(fake)
+
+			// The fake ignores the parameter, so the client still has to find
+			// the context among all of them.
+			context, err := client.ContextByName("gh", "test-org", "c")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(context.Name).To(Equal("c"))
+			Expect(*names).To(Equal([]string{"c", "c"}))
+		})
+
+		ginkgo.It("doesn't send the name by default", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			fake.addContext("a", time.Now())
+			names := namesSent(fake)
+
+			_, err := client.ContextByName("gh", "test-org", "a")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*names).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("WithEnvironmentVariablesByCreation", func() {
+		ginkgo.It("sorts variables by creation time", func() {
+			fake, server, client := newFakeContextServer(WithEnvironmentVariablesByCreation())
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now())
+			start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			fake.envVars[id] = []EnvironmentVariable{
+				{Variable: "NEWEST", ContextID: id, CreatedAt: start.Add(2 * time.Hour)},
+				{Variable: "OLDEST", ContextID: id, CreatedAt: start},
+				{Variable: "MIDDLE", ContextID: id, CreatedAt: start.Add(time.Hour)},
+			}
+
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			var names []string
+			for _, envVar := range *envVars {
+				names = append(names, envVar.Variable)
+			}
+			Expect(names).To(Equal([]string{"OLDEST", "MIDDLE", "NEWEST"}))
+		})
+
+		ginkgo.It("leaves variables in API order by default", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now())
+			start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			fake.envVars[id] = []EnvironmentVariable{
+				{Variable: "NEWER", ContextID: id, CreatedAt: start.Add(time.Hour)},
+				{Variable: "OLDER", ContextID: id, CreatedAt: start},
+			}
+
+			envVars, err := client.EnvironmentVariables(id)
+			Expect(err).ToNot(HaveOccurred())
+			Expect((*envVars)[0].Variable).To(Equal("NEWER"))
+		})
+	})
+
+	ginkgo.Describe("ContextsPaged", func() {
+		ginkgo.It("returns each page with its metadata", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			for _, name := range []string{"a", "b", "c"} {
+				fake.addContext(name, time.Now())
+			}
+
+			page, err := client.ContextsPaged("gh", "test-org", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.PageToken).To(BeEmpty())
+			Expect(page.NextPageToken).To(Equal("2"))
+			Expect(page.Len()).To(Equal(2))
+			Expect(page.Items[0].Name).To(Equal("a"))
+
+			page, err = client.ContextsPaged("gh", "test-org", page.NextPageToken)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.PageToken).To(Equal("2"))
+			Expect(page.NextPageToken).To(BeEmpty())
+			Expect(page.Len()).To(Equal(1))
+			Expect(page.Items[0].Name).To(Equal("c"))
+		})
+
+		ginkgo.It("pages through environment variables", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now(), "A", "B", "C")
+
+			page, err := client.EnvironmentVariablesPaged(id, "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.Len()).To(Equal(2))
+			Expect(page.NextPageToken).To(Equal("2"))
+
+			page, err = client.EnvironmentVariablesPaged(id, page.NextPageToken)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(page.Items).To(HaveLen(1))
+			Expect(page.Items[0].Variable).To(Equal("C"))
+			Expect(page.NextPageToken).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("GitLab organizations", func() {
+		var (
+			server  *httptest.Server
+			queries chan url.Values
+			bodies  chan map[string]interface{}
+		)
+
+		ginkgo.BeforeEach(func() {
+			queries = make(chan url.Values, 10)
+			bodies = make(chan map[string]interface{}, 10)
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				queries <- req.URL.Query()
+				if req.Method == "POST" {
+					var body map[string]interface{}
+					_ = json.NewDecoder(req.Body).Decode(&body)
+					bodies <- body
+					_, _ = rw.Write([]byte(`{"id": "new-context", "name": "ctx"}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"items": [{"id": "context-id", "name": "ctx"}]}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("lists contexts by owner ID", func() {
+			client := newTestRestClient(server)
+			contexts, err := client.ContextsByOwnerID("owner-uuid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*contexts).To(HaveLen(1))
+			Expect(<-queries).To(Equal(url.Values{"owner-id": {"owner-uuid"}}))
+		})
+
+		ginkgo.It("routes GitLab vcs types to the owner ID", func() {
+			client := newTestRestClient(server)
+			for _, vcs := range []string{"gitlab", "circleci"} {
+				_, err := client.Contexts(vcs, "owner-uuid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(<-queries).To(Equal(url.Values{"owner-id": {"owner-uuid"}}))
+			}
+
+			_, err := client.ContextByName("gitlab", "owner-uuid", "ctx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-queries).To(Equal(url.Values{"owner-id": {"owner-uuid"}}))
+
+			Expect(client.CreateContext("gitlab", "owner-uuid", "ctx")).To(Succeed())
+			<-queries
+			Expect((<-bodies)["owner"]).To(Equal(map[string]interface{}{"id": "owner-uuid"}))
+		})
+
+		ginkgo.It("keeps using the slug for other vcs types", func() {
+			client := newTestRestClient(server)
+			_, err := client.Contexts("github", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-queries).To(Equal(url.Values{"owner-slug": {"github/test-org"}}))
+		})
+	})
+
+	ginkgo.Describe("WithSlugBuilder", func() {
+		var (
+			server   *httptest.Server
+			requests []string
+		)
+
+		ginkgo.BeforeEach(func() {
+			requests = nil
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				requests = append(requests, req.URL.EscapedPath()+"?"+req.URL.Query().Encode())
+				if req.Method == "POST" {
+					_, _ = rw.Write([]byte(`{"id": "pipeline-id", "number": 1}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("builds org and project slugs with the given builder", func() {
+			client := newTestRestClient(server, WithSlugBuilder(func(vcs, org string) string {
+				return "enterprise/" + vcs + "-" + org
+			}))
+			_, err := client.Contexts("ghe", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.TriggerPipeline("ghe", "test-org", "test-project", "main", nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).To(Equal([]string{
+				"/api/v2/context?owner-slug=enterprise%2Fghe-test-org",
+				"/api/v2/project/enterprise/ghe-test-org/test-project/pipeline?",
+			}))
+		})
+
+		ginkgo.It("doesn't affect GitLab organizations", func() {
+			client := newTestRestClient(server, WithSlugBuilder(func(vcs, org string) string {
+				return "enterprise/" + org
+			}))
+			_, err := client.Contexts("gitlab", "owner-uuid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).To(Equal([]string{"/api/v2/context?owner-id=owner-uuid"}))
+		})
+	})
+
+	ginkgo.Describe("WithAcceptHeader", func() {
+		var (
+			server  *httptest.Server
+			accepts []string
+		)
+
+		ginkgo.BeforeEach(func() {
+			accepts = nil
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				accepts = append(accepts, req.Header.Get("Accept"))
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("sends the configured Accept header", func() {
+			client := newTestRestClient(server, WithAcceptHeader("application/vnd.circleci.v2+json"))
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(accepts).To(Equal([]string{"application/vnd.circleci.v2+json", "application/vnd.circleci.v2+json"}))
+		})
+
+		ginkgo.It("accepts JSON by default", func() {
+			_, err := newTestRestClient(server).Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(accepts).To(Equal([]string{"application/json"}))
+		})
+	})
+
+	ginkgo.Describe("WithMinimalResponses", func() {
+		var (
+			server  *httptest.Server
+			headers chan http.Header
+		)
+
+		ginkgo.BeforeEach(func() {
+			headers = make(chan http.Header, 10)
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				headers <- req.Header
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("sends a Prefer header on list requests", func() {
+			client := newTestRestClient(server, WithMinimalResponses())
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect((<-headers).Get("Prefer")).To(Equal("return=minimal"))
+		})
+
+		ginkgo.It("sends no Prefer header by default", func() {
+			client := newTestRestClient(server)
+			_, err := client.EnvironmentVariables("context-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect((<-headers).Get("Prefer")).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("reading response bodies", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusOK)
+				_, _ = rw.Write([]byte(`{"items": [`))
+				rw.(http.Flusher).Flush()
+				select {
+				case <-time.After(2 * time.Second):
+				case <-req.Context().Done():
+				}
+				_, _ = rw.Write([]byte(`]}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("aborts a slow body once the read timeout elapses", func() {
+			client := newTestRestClient(server, WithBodyReadTimeout(50*time.Millisecond))
+			start := time.Now()
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).To(MatchError("Timed out reading the response body after 50ms"))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+	})
+})
+
+var _ = ginkgo.Describe("NewContextRestClient", func() {
+	newClient := func(host string) *ContextRestClient {
+		client, err := NewContextRestClient(settings.Config{
+			Host:     host,
+			Endpoint: "api/v2",
+		})
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	ginkgo.It("leaves plain hosts alone", func() {
+		Expect(newClient("https://circleci.com").server).To(Equal("https://circleci.com/api/v2/"))
+		Expect(newClient("https://circleci.com/").server).To(Equal("https://circleci.com/api/v2/"))
+	})
+
+	ginkgo.It("strips pasted API paths and query strings", func() {
+		Expect(newClient("https://circleci.com/api/v2").server).To(Equal("https://circleci.com/api/v2/"))
+		Expect(newClient("https://circleci.com/api/v2/context?owner-slug=gh/test-org").server).To(Equal("https://circleci.com/api/v2/"))
+		Expect(newClient("https://circleci.example.com/api/v1.1/me#top").server).To(Equal("https://circleci.example.com/api/v2/"))
+	})
+
+	ginkgo.It("keeps legitimate base paths", func() {
+		Expect(newClient("https://example.com/circleci/").server).To(Equal("https://example.com/circleci/api/v2/"))
+		Expect(newClient("https://example.com/apis/v2/").server).To(Equal("https://example.com/apis/v2/api/v2/"))
+	})
+
+	ginkgo.It("keeps base paths followed by pasted API paths", func() {
+		Expect(newClient("https://example.com/circleci/api/v2/").server).To(Equal("https://example.com/circleci/api/v2/"))
+		Expect(newClient("https://example.com/circleci/api/v2/context?owner-slug=gh/test-org").server).To(Equal("https://example.com/circleci/api/v2/"))
+	})
+})
+
+var _ = ginkgo.Describe("Sending requests", func() {
+	type call struct {
+		name string
+		send func(client *ContextRestClient) error
+		// notFound is set for methods reporting a 404 with a NotFoundError.
+		notFound bool
+	}
+	calls := []call{
+		{"DeleteContext", func(c *ContextRestClient) error { return c.DeleteContext("context-id") }, false},
+		{"DeleteEnvironmentVariable", func(c *ContextRestClient) error { return c.DeleteEnvironmentVariable("context-id", "VAR") }, false},
+		{"CreateContext", func(c *ContextRestClient) error { return c.CreateContext("gh", "test-org", "ctx") }, false},
+		{"CreateEnvironmentVariable", func(c *ContextRestClient) error { return c.CreateEnvironmentVariable("context-id", "VAR", "value") }, false},
+		{"GetContextByID", func(c *ContextRestClient) error { _, err := c.GetContextByID("context-id"); return err }, true},
+		{"Contexts", func(c *ContextRestClient) error { _, err := c.Contexts("gh", "test-org"); return err }, false},
+		{"EnvironmentVariables", func(c *ContextRestClient) error { _, err := c.EnvironmentVariables("context-id"); return err }, false},
+		{"TriggerPipeline", func(c *ContextRestClient) error {
+			_, err := c.TriggerPipeline("gh", "test-org", "project", "main", nil)
+			return err
+		}, false},
+		{"GetPipelineConfig", func(c *ContextRestClient) error { _, err := c.GetPipelineConfig("pipeline-id"); return err }, true},
+		{"ListPipelinesForProject", func(c *ContextRestClient) error {
+			_, err := c.ListPipelinesForProject("gh", "test-org", "project", PipelineFilter{})
+			return err
+		}, false},
+		{"GetWorkflow", func(c *ContextRestClient) error { _, err := c.GetWorkflow("workflow-id"); return err }, true},
+		{"ApproveJob", func(c *ContextRestClient) error { return c.ApproveJob("workflow-id", "request-id") }, true},
+		{"ListJobsByWorkflow", func(c *ContextRestClient) error { _, err := c.ListJobsByWorkflow("workflow-id"); return err }, false},
+		{"GetOrganization", func(c *ContextRestClient) error { _, err := c.GetOrganization("gh", "test-org"); return err }, false},
+		{"FollowProject", func(c *ContextRestClient) error { _, err := c.FollowProject("gh", "test-org", "project"); return err }, true},
+		{"ListJobArtifacts", func(c *ContextRestClient) error {
+			_, err := c.ListJobArtifacts("gh", "test-org", "project", 1)
+			return err
+		}, true},
+		{"GetJobTests", func(c *ContextRestClient) error { _, err := c.GetJobTests("gh", "test-org", "project", 1); return err }, true},
+	}
+
+	var (
+		server *httptest.Server
+		client *ContextRestClient
+		status int
+		body   string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(status)
+			_, _ = rw.Write([]byte(body))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("reports unsuccessful statuses as an APIError from every method", func() {
+		status, body = http.StatusInternalServerError, `{"message": "Something broke"}`
+		for _, call := range calls {
+			err := call.send(client)
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue(), call.name)
+			Expect(apiErr.StatusCode).To(Equal(http.StatusInternalServerError), call.name)
+			Expect(apiErr.Message).To(Equal("Something broke"), call.name)
+		}
+	})
+
+	ginkgo.It("reports a 404 as a NotFoundError from methods looking up one resource", func() {
+		status, body = http.StatusNotFound, `{"message": "Not found."}`
+		for _, call := range calls {
+			err := call.send(client)
+			Expect(err).To(HaveOccurred(), call.name)
+			Expect(IsNotFoundError(err)).To(Equal(call.notFound), call.name)
+		}
+	})
+
+	ginkgo.It("treats any 2xx status as success", func() {
+		status, body = http.StatusAccepted, `{"message": "Accepted."}`
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.DeleteEnvironmentVariable("context-id", "VAR")).To(Succeed())
+		Expect(client.ApproveJob("workflow-id", "request-id")).To(Succeed())
+
+		status, body = http.StatusCreated, `{"id": "context-id", "name": "ctx"}`
+		Expect(client.CreateContext("gh", "test-org", "ctx")).To(Succeed())
+	})
+
+	ginkgo.It("keeps the special errors of the OpenAPI requests", func() {
+		status, body = http.StatusServiceUnavailable, ""
+		Expect(client.EnsureExists()).To(MatchError("API v2 test request failed."))
+		_, err := client.APIVersion()
+		Expect(err).To(MatchError(HavePrefix("The API version is unavailable")))
+	})
+})
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+)
+
+// decompressBody decodes bodyBytes according to the Content-Encoding of resp.
+// The transport already decodes the gzip responses it asked for, but some
+// CDNs answer with Brotli when a request editor advertises "br". Other
+// encodings are left as they are.
+func decompressBody(resp *http.Response, bodyBytes []byte) ([]byte, error) {
+	if !strings.EqualFold(strings.TrimSpace(resp.Header.Get("Content-Encoding")), "br") {
+		return bodyBytes, nil
+	}
+	decoded, err := decodeBrotli(bodyBytes)
+	if err != nil {
+		return nil, err
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Uncompressed = true
+	return decoded, nil
+}
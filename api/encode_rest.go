@@ -0,0 +1,53 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// An Encoder writes v, the body of a request, to w as JSON.
+type Encoder func(w io.Writer, v interface{}) error
+
+// WithEncoder replaces the encoder of request bodies, which by default is
+// json.Marshal. It is for callers who need other JSON settings, such as a
+// json.Encoder which doesn't escape HTML in parameter values.
+func WithEncoder(encode Encoder) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.encode = encode
+	}
+}
+
+func marshalJSON(w io.Writer, v interface{}) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// encodeBody encodes body with the client's encoder. The result is a
+// *bytes.Reader, so that the request can be replayed when retrying.
+func (c *ContextRestClient) encodeBody(body interface{}) (io.Reader, error) {
+	var buf bytes.Buffer
+	if err := c.encode(&buf, body); err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(buf.Bytes()), nil
+}
+
+// newJSONRequest builds a request whose body is body encoded as JSON.
+func (c *ContextRestClient) newJSONRequest(method, url string, body interface{}) (*http.Request, error) {
+	bodyReader, err := c.encodeBody(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newHTTPRequest(method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
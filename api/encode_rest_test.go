@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Encoding request bodies", func() {
+	var (
+		server      *httptest.Server
+		body        string
+		contentType string
+		requests    int
+	)
+
+	ginkgo.BeforeEach(func() {
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			requests++
+			raw, err := ioutil.ReadAll(req.Body)
+			Expect(err).ToNot(HaveOccurred())
+			body = string(raw)
+			contentType = req.Header.Get("Content-Type")
+			_, _ = rw.Write([]byte(`{"id": "context-id", "name": "deploy", "variable": "FOO"}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("encodes a create body as JSON", func() {
+		client := newTestRestClient(server)
+		Expect(client.CreateContext("gh", "test-org", "deploy")).To(Succeed())
+		Expect(contentType).To(Equal("application/json"))
+		Expect(body).To(Equal(`{"name":"deploy","owner":{"slug":"gh/test-org"}}`))
+	})
+
+	ginkgo.It("escapes HTML by default", func() {
+		client := newTestRestClient(server)
+		Expect(client.CreateEnvironmentVariable("context-id", "FOO", "<b>")).To(Succeed())
+		Expect(body).To(Equal(`{"value":"\u003cb\u003e"}`))
+	})
+
+	ginkgo.It("uses the encoder it is given", func() {
+		client := newTestRestClient(server, WithEncoder(func(w io.Writer, v interface{}) error {
+			encoder := json.NewEncoder(w)
+			encoder.SetEscapeHTML(false)
+			return encoder.Encode(v)
+		}))
+		Expect(client.CreateEnvironmentVariable("context-id", "FOO", "<b>")).To(Succeed())
+		Expect(contentType).To(Equal("application/json"))
+		Expect(body).To(Equal(`{"value":"<b>"}` + "\n"))
+	})
+
+	ginkgo.It("doesn't send a body which can't be encoded", func() {
+		client := newTestRestClient(server, WithEncoder(func(w io.Writer, v interface{}) error {
+			return errors.New("encoding failed")
+		}))
+		Expect(client.CreateContext("gh", "test-org", "name")).To(MatchError("encoding failed"))
+		Expect(requests).To(Equal(0))
+	})
+})
@@ -0,0 +1,187 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrEmptyResponseBody is returned when a successful response has no body,
+// but the details of a resource were expected.
+var ErrEmptyResponseBody = errors.New("The server returned an empty response body")
+
+// An APIError is returned when the CircleCI REST API responds with an
+// unsuccessful status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+	// Body holds the start of the response body when it wasn't JSON, as
+	// returned by some proxies and gateways.
+	Body string
+	// RequestID is the X-Request-Id header of the response, which CircleCI
+	// support can use to find the request. It is empty if there was none.
+	RequestID string
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Body != "" {
+		return fmt.Sprintf("response %d (%s): %s", e.StatusCode, statusText(e.StatusCode), e.Body)
+	}
+	return fmt.Sprintf("response %d (%s)", e.StatusCode, statusText(e.StatusCode))
+}
+
+// statusText is like http.StatusText, but also describes codes which aren't
+// standard, as some proxies and gateways return.
+func statusText(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return text
+	}
+	return "Unknown Status"
+}
+
+// newAPIError builds an APIError from a failed response and its body. The
+// body is decoded as JSON if it is labelled as JSON or looks like a JSON
+// object; otherwise, or if it can't be decoded, its text is kept as is, so
+// that errors from proxies which answer in plain text or HTML aren't masked
+// by a decoding failure. Without a message or text, the error describes the
+// status.
+func newAPIError(resp *http.Response, bodyBytes []byte) error {
+	apiErr := &APIError{StatusCode: resp.StatusCode, RequestID: resp.Header.Get(requestIDHeader)}
+	trimmed := bytes.TrimSpace(bodyBytes)
+	if len(trimmed) == 0 {
+		return apiErr
+	}
+	if !isJSONContentType(resp.Header.Get("Content-Type")) && trimmed[0] != '{' {
+		apiErr.Body = truncate(string(trimmed), maxDecodeErrorSnippet)
+		return apiErr
+	}
+
+	var dest errorResponse
+	if err := json.Unmarshal(trimmed, &dest); err != nil {
+		apiErr.Body = truncate(string(trimmed), maxDecodeErrorSnippet)
+		return apiErr
+	}
+	if dest.Message != nil {
+		apiErr.Message = *dest.Message
+	}
+	return apiErr
+}
+
+// isJSONContentType reports whether contentType is application/json or a
+// JSON-based type such as application/problem+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// maxDecodeErrorSnippet is how much of an undecodable body a DecodeError,
+// or a non-JSON body an APIError, keeps.
+const maxDecodeErrorSnippet = 200
+
+// A DecodeError is returned when a response body isn't the JSON that was
+// expected, for example because a proxy answered with an HTML error page.
+type DecodeError struct {
+	StatusCode int
+	// Snippet is the start of the response body.
+	Snippet string
+	Err     error
+}
+
+func newDecodeError(statusCode int, bodyBytes []byte, err error) *DecodeError {
+	snippet := truncate(string(bodyBytes), maxDecodeErrorSnippet)
+	return &DecodeError{StatusCode: statusCode, Snippet: snippet, Err: err}
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("Unable to decode the response (%d %s) as JSON: %s. The response began: %s",
+		e.StatusCode, statusText(e.StatusCode), e.Err, e.Snippet)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// NotFoundError is returned when a requested resource does not exist.
+type NotFoundError struct {
+	Message string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Message
+}
+
+// IsNotFoundError reports whether err is, or wraps, a NotFoundError.
+func IsNotFoundError(err error) bool {
+	var notFound *NotFoundError
+	return errors.As(err, &notFound)
+}
+
+// IsConflictError reports whether err is, or wraps, an APIError for a 409
+// Conflict, as returned when creating a resource which already exists.
+func IsConflictError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict
+}
+
+// IsRetryable reports whether the request that failed with err is worth
+// retrying. Server errors (5xx), rate limiting (429) and network timeouts are
+// retryable; other client errors (4xx) are permanent, as are cancellations and
+// expired deadlines of the caller's context.
+func IsRetryable(err error) bool {
+	if errors.Is(err, context.Canceled) || wrapsDeadlineExceeded(err) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+	return false
+}
+
+// wrapsDeadlineExceeded reports whether err is, or wraps, the error of an
+// expired context. It doesn't use errors.Is, which also matches the timeouts
+// of an http.Client; those are network timeouts, and worth retrying.
+func wrapsDeadlineExceeded(err error) bool {
+	for err != nil {
+		if err == context.DeadlineExceeded {
+			return true
+		}
+		switch e := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range e.Unwrap() {
+				if wrapsDeadlineExceeded(err) {
+					return true
+				}
+			}
+			return false
+		case interface{ Unwrap() error }:
+			err = e.Unwrap()
+		default:
+			return false
+		}
+	}
+	return false
+}
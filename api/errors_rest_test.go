@@ -0,0 +1,301 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("REST errors", func() {
+	ginkgo.Describe("IsRetryable", func() {
+		ginkgo.It("retries server errors and rate limiting", func() {
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusInternalServerError})).To(BeTrue())
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusServiceUnavailable})).To(BeTrue())
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusTooManyRequests})).To(BeTrue())
+			Expect(IsRetryable(fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusBadGateway}))).To(BeTrue())
+		})
+
+		ginkgo.It("does not retry client errors", func() {
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusBadRequest})).To(BeFalse())
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusUnauthorized})).To(BeFalse())
+			Expect(IsRetryable(&APIError{StatusCode: http.StatusNotFound})).To(BeFalse())
+			Expect(IsRetryable(errors.New("something else"))).To(BeFalse())
+		})
+
+		ginkgo.It("does not retry cancelled or expired contexts", func() {
+			Expect(IsRetryable(context.Canceled)).To(BeFalse())
+			Expect(IsRetryable(context.DeadlineExceeded)).To(BeFalse())
+			Expect(IsRetryable(fmt.Errorf("wrapped: %w", context.DeadlineExceeded))).To(BeFalse())
+			Expect(IsRetryable(&url.Error{Op: "Get", URL: "https://circleci.com", Err: context.DeadlineExceeded})).To(BeFalse())
+		})
+
+		ginkgo.It("retries network timeouts", func() {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				select {
+				case <-time.After(time.Second):
+				case <-req.Context().Done():
+				}
+			}))
+			defer server.Close()
+
+			client := newTestRestClient(server)
+			client.client = &http.Client{Timeout: 10 * time.Millisecond}
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).To(HaveOccurred())
+			Expect(IsRetryable(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("conflicts", func() {
+		ginkgo.It("identifies a duplicate create", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			fake.fail = func(req *http.Request) int {
+				if req.Method == "POST" || req.Method == "PUT" {
+					return http.StatusConflict
+				}
+				return 0
+			}
+
+			err := client.CreateContext("gh", "test-org", "ctx")
+			Expect(IsConflictError(err)).To(BeTrue())
+			err = client.CreateEnvironmentVariable("context-id", "FOO", "value")
+			Expect(IsConflictError(err)).To(BeTrue())
+			Expect(IsConflictError(fmt.Errorf("wrapped: %w", err))).To(BeTrue())
+
+			Expect(IsConflictError(&APIError{StatusCode: http.StatusBadRequest})).To(BeFalse())
+			Expect(IsConflictError(errors.New("something else"))).To(BeFalse())
+		})
+
+		ginkgo.It("returns the existing context when a create races", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			fake.fail = func(req *http.Request) int {
+				if req.Method == "POST" {
+					fake.addContext("ctx", time.Now())
+					return http.StatusConflict
+				}
+				return 0
+			}
+
+			context, created, err := client.GetOrCreateContext("gh", "test-org", "ctx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created).To(BeFalse())
+			Expect(context.Name).To(Equal("ctx"))
+		})
+	})
+
+	ginkgo.It("returns an APIError for unsuccessful responses", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		fake.fail = func(*http.Request) int { return http.StatusForbidden }
+
+		err := client.DeleteContext("context-id")
+		Expect(err).To(MatchError("Forbidden"))
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusForbidden))
+	})
+	ginkgo.Describe("empty response bodies", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method == "GET" {
+					_, _ = rw.Write([]byte(`{"items": []}`))
+					return
+				}
+				rw.WriteHeader(http.StatusCreated)
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("treats a created context with no body as a success", func() {
+			client := newTestRestClient(server)
+			Expect(client.CreateContext("gh", "test-org", "ctx")).To(Succeed())
+		})
+
+		ginkgo.It("returns a clear error when the created resource was needed", func() {
+			client := newTestRestClient(server)
+			_, created, err := client.GetOrCreateContext("gh", "test-org", "ctx")
+			Expect(err).To(Equal(ErrEmptyResponseBody))
+			Expect(created).To(BeTrue())
+
+			_, err = client.TriggerPipeline("gh", "test-org", "project", "main", nil)
+			Expect(err).To(MatchError("The server returned an empty response body"))
+		})
+
+		ginkgo.It("looks up a created context with no body", func() {
+			fake := newFakeContextAPI()
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method == "POST" {
+					fake.addContext("ctx", time.Now())
+					rw.WriteHeader(http.StatusCreated)
+					return
+				}
+				fake.ServeHTTP(rw, req)
+			}))
+			defer server.Close()
+			var events []AuditEvent
+			client := newTestRestClient(server, WithAuditSink(func(event AuditEvent) {
+				events = append(events, event)
+			}))
+
+			context, created, err := client.GetOrCreateContext("gh", "test-org", "ctx")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(created).To(BeTrue())
+			Expect(context.Name).To(Equal("ctx"))
+			Expect(context.ID).ToNot(BeEmpty())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].ResourceID).To(Equal(context.ID))
+		})
+	})
+	ginkgo.Describe("undecodable responses", func() {
+		var (
+			server *httptest.Server
+			status int
+		)
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", "text/html")
+				rw.WriteHeader(status)
+				_, _ = rw.Write([]byte("<html><body>" + strings.Repeat("Oops! ", 100) + "</body></html>"))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns a DecodeError for an HTML success body", func() {
+			status = http.StatusOK
+			client := newTestRestClient(server)
+			_, err := client.Contexts("gh", "test-org")
+
+			var decodeErr *DecodeError
+			Expect(errors.As(err, &decodeErr)).To(BeTrue())
+			Expect(decodeErr.StatusCode).To(Equal(http.StatusOK))
+			Expect(decodeErr.Snippet).To(HavePrefix("<html><body>Oops!"))
+			Expect(decodeErr.Snippet).To(HaveLen(maxDecodeErrorSnippet + len("...")))
+			Expect(err.Error()).To(HavePrefix("Unable to decode the response (200 OK) as JSON: invalid character '<' looking for beginning of value. The response began: <html>"))
+		})
+
+		ginkgo.It("returns an APIError with the text of an HTML error body", func() {
+			status = http.StatusBadGateway
+			client := newTestRestClient(server)
+			err := client.DeleteContext("context-id")
+
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusBadGateway))
+			Expect(apiErr.Body).To(HavePrefix("<html><body>Oops!"))
+			Expect(err.Error()).To(HavePrefix("response 502 (Bad Gateway): <html>"))
+		})
+	})
+
+	ginkgo.Describe("error bodies", func() {
+		var (
+			server      *httptest.Server
+			contentType string
+			body        string
+		)
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.Header().Set("Content-Type", contentType)
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				_, _ = rw.Write([]byte(body))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("keeps the text of a plain text error", func() {
+			contentType = "text/plain"
+			body = "upstream connect error or disconnect/reset before headers\n"
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 503 (Service Unavailable): upstream connect error or disconnect/reset before headers"))
+			Expect(IsRetryable(err)).To(BeTrue())
+		})
+
+		ginkgo.It("keeps the text of an XML error", func() {
+			contentType = "application/xml"
+			body = "<Error><Code>ServiceUnavailable</Code></Error>"
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 503 (Service Unavailable): <Error><Code>ServiceUnavailable</Code></Error>"))
+		})
+
+		ginkgo.It("decodes JSON errors", func() {
+			contentType = "application/problem+json; charset=utf-8"
+			body = `{"message": "Try again later."}`
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("Try again later."))
+		})
+
+		ginkgo.It("reports the status of an empty error", func() {
+			contentType = "text/plain"
+			body = ""
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 503 (Service Unavailable)"))
+		})
+
+		ginkgo.It("keeps the text of a JSON error which can't be decoded", func() {
+			contentType = "application/json"
+			body = `{"message": "cut of`
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(BeAssignableToTypeOf(&APIError{}))
+			Expect(err).To(MatchError(`response 503 (Service Unavailable): {"message": "cut of`))
+		})
+
+		ginkgo.It("reports the status of a JSON error without a message", func() {
+			contentType = "application/json"
+			body = `{"error": "upstream"}`
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 503 (Service Unavailable)"))
+		})
+	})
+
+	ginkgo.Describe("status text", func() {
+		var (
+			server *httptest.Server
+			status int
+		)
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(status)
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("describes a bad gateway with no body", func() {
+			status = http.StatusBadGateway
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 502 (Bad Gateway)"))
+		})
+
+		ginkgo.It("describes codes which aren't standard", func() {
+			status = 599
+			err := newTestRestClient(server).DeleteContext("context-id")
+			Expect(err).To(MatchError("response 599 (Unknown Status)"))
+		})
+	})
+})
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+)
+
+// WithConditionalRequests makes the client remember the ETag and body of each
+// successful GET response, and send the ETag as If-None-Match when the same
+// URL is requested again. A 304 Not Modified answer is then treated as the
+// remembered 200 OK response, so that polling a pipeline or workflow which
+// hasn't changed costs no more than its headers, and returns the same result
+// as before. The remembered bodies are kept for the life of the client, so
+// this suits clients polling a bounded set of resources.
+func WithConditionalRequests() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.etags = &etagCache{}
+	}
+}
+
+type etagEntry struct {
+	etag string
+	body []byte
+}
+
+// etagCache is safe for concurrent use.
+type etagCache struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+func (e *etagCache) get(url string) (etagEntry, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	entry, ok := e.entries[url]
+	return entry, ok
+}
+
+func (e *etagCache) put(url string, entry etagEntry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.entries == nil {
+		e.entries = map[string]etagEntry{}
+	}
+	e.entries[url] = entry
+}
+
+// conditionalRequest adds If-None-Match to req if it is a GET whose response
+// has been remembered, returning the remembered response.
+func (c *ContextRestClient) conditionalRequest(req *http.Request) (etagEntry, bool) {
+	if c.etags == nil || req.Method != http.MethodGet {
+		return etagEntry{}, false
+	}
+	entry, ok := c.etags.get(req.URL.String())
+	if ok {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+	return entry, ok
+}
+
+// conditionalResponse remembers resp if it is a successful GET with an ETag,
+// or, if it is a 304 for the remembered response cached, turns it into that
+// response, returning the body to use in either case.
+func (c *ContextRestClient) conditionalResponse(req *http.Request, resp *http.Response, bodyBytes []byte, cached etagEntry, isCached bool) []byte {
+	if c.etags == nil || req.Method != http.MethodGet {
+		return bodyBytes
+	}
+	if resp.StatusCode == http.StatusNotModified && isCached {
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		return cached.body
+	}
+	if etag := resp.Header.Get("ETag"); etag != "" && resp.StatusCode == http.StatusOK {
+		c.etags.put(req.URL.String(), etagEntry{etag: etag, body: bodyBytes})
+	}
+	return bodyBytes
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WithConditionalRequests", func() {
+	var (
+		server      *httptest.Server
+		noneMatches []string
+		status      string
+	)
+
+	ginkgo.BeforeEach(func() {
+		noneMatches = nil
+		status = "running"
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			noneMatches = append(noneMatches, req.Header.Get("If-None-Match"))
+			etag := `"` + status + `"`
+			if req.Header.Get("If-None-Match") == etag {
+				rw.WriteHeader(http.StatusNotModified)
+				return
+			}
+			rw.Header().Set("ETag", etag)
+			_, _ = rw.Write([]byte(`{"id": "workflow-id", "status": "` + status + `"}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("returns the remembered result when the server says it isn't modified", func() {
+		client := newTestRestClient(server, WithConditionalRequests())
+		for i := 0; i < 2; i++ {
+			workflow, err := client.GetWorkflow("workflow-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(workflow.Status).To(Equal("running"))
+		}
+		Expect(noneMatches).To(Equal([]string{"", `"running"`}))
+	})
+
+	ginkgo.It("remembers the new response when it has changed", func() {
+		client := newTestRestClient(server, WithConditionalRequests())
+		_, err := client.GetWorkflow("workflow-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		status = "success"
+		workflow, err := client.GetWorkflow("workflow-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workflow.Status).To(Equal("success"))
+
+		workflow, err = client.GetWorkflow("workflow-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(workflow.Status).To(Equal("success"))
+		Expect(noneMatches).To(Equal([]string{"", `"running"`, `"success"`}))
+	})
+
+	ginkgo.It("doesn't send If-None-Match by default", func() {
+		client := newTestRestClient(server)
+		for i := 0; i < 2; i++ {
+			_, err := client.GetWorkflow("workflow-id")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(noneMatches).To(Equal([]string{"", ""}))
+	})
+})
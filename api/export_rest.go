@@ -0,0 +1,129 @@
+package api
+
+import (
+	"context"
+	"sort"
+)
+
+// An OrgExport describes the contexts of an org and the names of their
+// environment variables, for backing them up or migrating them to another
+// org with ImportOrg. It can be serialized as JSON.
+//
+// The values of environment variables are NOT exported: the API never
+// returns them once they are stored. Restoring an export therefore requires
+// the values to be supplied from elsewhere, such as a secrets manager.
+type OrgExport struct {
+	VCS      string          `json:"vcs"`
+	Org      string          `json:"org"`
+	Contexts []ContextExport `json:"contexts"`
+}
+
+// A ContextExport describes a single context of an OrgExport.
+type ContextExport struct {
+	Name      string   `json:"name"`
+	Variables []string `json:"variables"`
+}
+
+// ExportOrg exports the contexts owned by the given org and the names of their
+// environment variables, sorted by name. The variables of each context are
+// listed concurrently, as configured by opts. If some contexts fail, they are
+// left out and the export of the others is returned alongside a *BatchError
+// keyed by context ID.
+func (c *ContextRestClient) ExportOrg(vcs, org string, opts ...BatchOption) (*OrgExport, error) {
+	contexts, err := c.Contexts(vcs, org)
+	if err != nil {
+		return nil, err
+	}
+
+	envVars, err := c.environmentVariablesOf(*contexts, opts)
+
+	export := &OrgExport{
+		VCS:      vcs,
+		Org:      org,
+		Contexts: []ContextExport{},
+	}
+	for _, context := range *contexts {
+		vars, ok := envVars[context.ID]
+		if !ok {
+			continue
+		}
+		names := make([]string, 0, len(vars))
+		for _, envVar := range vars {
+			names = append(names, envVar.Variable)
+		}
+		sort.Strings(names)
+		export.Contexts = append(export.Contexts, ContextExport{
+			Name:      context.Name,
+			Variables: names,
+		})
+	}
+	sort.Slice(export.Contexts, func(i, j int) bool {
+		return export.Contexts[i].Name < export.Contexts[j].Name
+	})
+	return export, err
+}
+
+// A ValueResolver supplies the value of an environment variable being
+// imported by ImportOrg, since exports don't contain values.
+type ValueResolver func(contextName, variable string) (string, error)
+
+// ImportOrg recreates the contexts and environment variables described by
+// export in the given org, asking resolve for the value of each variable it
+// creates. It is idempotent: contexts and variables which already exist are
+// skipped, and resolve is not asked for their values, so existing values are
+// never overwritten. The results report what was created and skipped.
+//
+// Requests are made one at a time; configure the client WithRetries to retry
+// those which are rate limited. ImportOrg stops at the first failure, or when
+// ctx is cancelled, abandoning any request in flight, and returns the results
+// of the contexts imported so far.
+func (c *ContextRestClient) ImportOrg(ctx context.Context, vcs, org string, export *OrgExport, resolve ValueResolver) ([]ContextApplyResult, error) {
+	results := make([]ContextApplyResult, 0, len(export.Contexts))
+	for _, spec := range export.Contexts {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
+		context, created, err := c.getOrCreateContext(ctx, vcs, org, spec.Name)
+		if err != nil {
+			return results, err
+		}
+		result := ContextApplyResult{
+			Name:      spec.Name,
+			ContextID: context.ID,
+			Created:   created,
+		}
+
+		existing := map[string]bool{}
+		if !created {
+			envVars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+				ContextID: &context.ID,
+			})
+			if err != nil {
+				return results, err
+			}
+			for _, envVar := range envVars {
+				existing[envVar.Variable] = true
+			}
+		}
+
+		variables := append([]string(nil), spec.Variables...)
+		sort.Strings(variables)
+		for _, variable := range variables {
+			if existing[variable] {
+				result.UnchangedVariables = append(result.UnchangedVariables, variable)
+				continue
+			}
+			value, err := resolve(spec.Name, variable)
+			if err != nil {
+				return results, err
+			}
+			if _, err := c.putEnvironmentVariable(ctx, context.ID, variable, value); err != nil && err != ErrEmptyResponseBody {
+				return results, err
+			}
+			result.CreatedVariables = append(result.CreatedVariables, variable)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
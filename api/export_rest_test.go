@@ -0,0 +1,172 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ExportOrg", func() {
+	var (
+		fake   *fakeContextAPI
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+		fake.addContext("staging", time.Now(), "TOKEN", "API_KEY")
+		fake.addContext("empty", time.Now())
+		fake.addContext("production", time.Now(), "TOKEN")
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("exports contexts and variable names sorted by name", func() {
+		export, err := client.ExportOrg("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*export).To(Equal(OrgExport{
+			VCS: "gh",
+			Org: "test-org",
+			Contexts: []ContextExport{
+				{Name: "empty", Variables: []string{}},
+				{Name: "production", Variables: []string{"TOKEN"}},
+				{Name: "staging", Variables: []string{"API_KEY", "TOKEN"}},
+			},
+		}))
+
+		serialized, err := json.Marshal(export)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(serialized)).To(Equal(`{"vcs":"gh","org":"test-org","contexts":[` +
+			`{"name":"empty","variables":[]},` +
+			`{"name":"production","variables":["TOKEN"]},` +
+			`{"name":"staging","variables":["API_KEY","TOKEN"]}]}`))
+	})
+
+	ginkgo.It("returns a partial export when some contexts fail", func() {
+		fake.fail = func(req *http.Request) int {
+			if strings.HasPrefix(req.URL.Path, "/api/v2/context/context-2/") {
+				return http.StatusInternalServerError
+			}
+			return 0
+		}
+		export, err := client.ExportOrg("gh", "test-org")
+		Expect(err).To(BeAssignableToTypeOf(&BatchError{}))
+		Expect(err.(*BatchError).Errors).To(HaveKey("context-2"))
+		Expect(export.Contexts).To(HaveLen(2))
+		Expect(export.Contexts[0].Name).To(Equal("production"))
+	})
+})
+
+var _ = ginkgo.Describe("ImportOrg", func() {
+	var (
+		fake     *fakeContextAPI
+		server   *httptest.Server
+		client   *ContextRestClient
+		export   *OrgExport
+		resolved []string
+		resolve  ValueResolver
+	)
+
+	ginkgo.BeforeEach(func() {
+		fake, server, client = newFakeContextServer()
+		export = &OrgExport{
+			VCS: "gh",
+			Org: "old-org",
+			Contexts: []ContextExport{
+				{Name: "production", Variables: []string{"TOKEN", "API_KEY"}},
+				{Name: "staging", Variables: []string{"TOKEN"}},
+			},
+		}
+		resolved = nil
+		resolve = func(contextName, variable string) (string, error) {
+			resolved = append(resolved, contextName+"/"+variable)
+			return "value-of-" + variable, nil
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("recreates contexts with values from the resolver", func() {
+		results, err := client.ImportOrg(context.Background(), "gh", "new-org", export, resolve)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+		Expect(results[0].Created).To(BeTrue())
+		Expect(results[0].CreatedVariables).To(Equal([]string{"API_KEY", "TOKEN"}))
+		Expect(resolved).To(Equal([]string{"production/API_KEY", "production/TOKEN", "staging/TOKEN"}))
+		Expect(fake.values).To(HaveKeyWithValue(results[0].ContextID+"/TOKEN", "value-of-TOKEN"))
+	})
+
+	ginkgo.It("skips contexts and variables which already exist", func() {
+		id := fake.addContext("production", time.Now(), "TOKEN")
+		results, err := client.ImportOrg(context.Background(), "gh", "new-org", export, resolve)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(results[0].ContextID).To(Equal(id))
+		Expect(results[0].Created).To(BeFalse())
+		Expect(results[0].CreatedVariables).To(Equal([]string{"API_KEY"}))
+		Expect(results[0].UnchangedVariables).To(Equal([]string{"TOKEN"}))
+		Expect(resolved).To(Equal([]string{"production/API_KEY", "staging/TOKEN"}))
+
+		resolved = nil
+		_, err = client.ImportOrg(context.Background(), "gh", "new-org", export, resolve)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(resolved).To(BeEmpty())
+	})
+
+	ginkgo.It("stops at the first error from the resolver", func() {
+		resolve = func(contextName, variable string) (string, error) {
+			return "", errors.New("secret not found")
+		}
+		results, err := client.ImportOrg(context.Background(), "gh", "new-org", export, resolve)
+		Expect(err).To(MatchError("secret not found"))
+		Expect(results).To(BeEmpty())
+	})
+
+	ginkgo.It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		results, err := client.ImportOrg(ctx, "gh", "new-org", export, func(contextName, variable string) (string, error) {
+			cancel()
+			return "value", nil
+		})
+		Expect(err).To(MatchError(context.Canceled))
+		Expect(results).To(BeEmpty())
+		Expect(fake.values).To(BeEmpty())
+	})
+
+	ginkgo.It("cancels a context creation in flight", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		fake.fail = func(req *http.Request) int {
+			if req.Method != "POST" {
+				return 0
+			}
+			cancel()
+			// Hold the request until the client gives up on it. The server
+			// only notices that once the body has been read.
+			_, _ = io.Copy(ioutil.Discard, req.Body)
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return http.StatusInternalServerError
+		}
+
+		start := time.Now()
+		_, err := client.ImportOrg(ctx, "gh", "new-org", export, resolve)
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+	})
+})
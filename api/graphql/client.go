@@ -64,6 +64,10 @@ type Request struct {
 	// Header represent any request headers that will be set
 	// when the request is made.
 	Header http.Header `json:"-"`
+
+	// secrets are masked wherever the client logs the request or its
+	// response.
+	secrets []string
 }
 
 // SetToken sets the Authorization header for the request with the given token.
@@ -76,6 +80,26 @@ func (request *Request) Var(key string, value interface{}) {
 	request.Variables[key] = value
 }
 
+// Secret marks a value, such as the value of an environment variable, that
+// must not appear in the client's debug output.
+func (request *Request) Secret(value string) {
+	if value != "" {
+		request.secrets = append(request.secrets, value)
+	}
+}
+
+// redactedValue masks secrets in the debug output, the same way
+// api.RedactValue masks them for display.
+const redactedValue = "****"
+
+// redact masks every secret of the request in s.
+func (request *Request) redact(s string) string {
+	for _, secret := range request.secrets {
+		s = strings.ReplaceAll(s, secret, redactedValue)
+	}
+	return s
+}
+
 // Encode will return a buffer of the JSON encoded request body
 func (request *Request) Encode() (bytes.Buffer, error) {
 	var body bytes.Buffer
@@ -220,7 +244,7 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 	}
 
 	if cl.Debug {
-		l.Printf(">> variables: %v", request.Variables)
+		l.Printf(">> variables: %s", request.redact(fmt.Sprintf("%v", request.Variables)))
 		l.Printf(">> query: %s", request.Query)
 	}
 
@@ -254,7 +278,7 @@ func (cl *Client) Run(request *Request, resp interface{}) error {
 				return errors.Wrap(err, "reading response")
 			}
 
-			l.Printf("<< %s", string(bodyBytes))
+			l.Printf("<< %s", request.redact(string(bodyBytes)))
 
 			// Restore the io.ReadCloser to its original state
 			res.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
@@ -5,7 +5,9 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"regexp"
+	"strings"
 	"testing"
 )
 
@@ -317,3 +319,54 @@ func TestStatusCode413(t *testing.T) {
 		t.Errorf("expected %d", calls)
 	}
 }
+
+func TestDebugRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := io.WriteString(w, `{"data":{"value":"s3cr3t"}}`)
+		if err != nil {
+			t.Errorf(err.Error())
+		}
+	}))
+	defer srv.Close()
+
+	stderr := os.Stderr
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stderr = writer
+	defer func() { os.Stderr = stderr }()
+
+	client := NewClient(http.DefaultClient, srv.URL, "/", "token", true)
+
+	req := NewRequest("query {}")
+	req.Var("input", map[string]string{"value": "s3cr3t"})
+	req.Secret("s3cr3t")
+
+	var resp struct {
+		Value string
+	}
+	err = client.Run(req, &resp)
+	os.Stderr = stderr
+	writer.Close()
+	if err != nil {
+		t.Errorf(err.Error())
+	}
+
+	logged, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(logged), "s3cr3t") {
+		t.Errorf("expected the secret to be masked in %s", logged)
+	}
+
+	if !strings.Contains(string(logged), "map[input:map[value:****]]") {
+		t.Errorf("expected the masked variables in %s", logged)
+	}
+
+	if resp.Value != "s3cr3t" {
+		t.Errorf("expected %+v", resp)
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WithGzipRequests", func() {
+	var (
+		server   *httptest.Server
+		encoding string
+		value    string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			encoding = req.Header.Get("Content-Encoding")
+			var body io.Reader = req.Body
+			if encoding == "gzip" {
+				reader, err := gzip.NewReader(req.Body)
+				Expect(err).ToNot(HaveOccurred())
+				body = reader
+			}
+			raw, err := ioutil.ReadAll(body)
+			Expect(err).ToNot(HaveOccurred())
+			var dest struct {
+				Value string `json:"value"`
+			}
+			Expect(json.Unmarshal(raw, &dest)).To(Succeed())
+			value = dest.Value
+			_, _ = rw.Write([]byte(`{}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("compresses bodies over the threshold", func() {
+		client := newTestRestClient(server, WithGzipRequests(100))
+		large := strings.Repeat("x", 1000)
+		Expect(client.CreateEnvironmentVariable("context-id", "FOO", large)).To(Succeed())
+		Expect(encoding).To(Equal("gzip"))
+		Expect(value).To(Equal(large))
+	})
+
+	ginkgo.It("sends bodies under the threshold uncompressed", func() {
+		client := newTestRestClient(server, WithGzipRequests(100))
+		Expect(client.CreateEnvironmentVariable("context-id", "FOO", "small")).To(Succeed())
+		Expect(encoding).To(BeEmpty())
+		Expect(value).To(Equal("small"))
+	})
+
+	ginkgo.It("does not compress by default", func() {
+		client := newTestRestClient(server)
+		Expect(client.CreateEnvironmentVariable("context-id", "FOO", strings.Repeat("x", 1000))).To(Succeed())
+		Expect(encoding).To(BeEmpty())
+	})
+})
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// A RequestEditor changes a request before it is sent, for example to sign it
+// or to add tracing headers. Returning an error aborts the request.
+type RequestEditor func(req *http.Request) error
+
+// WithRequestEditor adds an editor which is run on every request the client
+// sends, after the client has built it. Editors run in the order they were
+// added, and are run again before each retry, so they should Set headers
+// rather than Add them.
+func WithRequestEditor(editor RequestEditor) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.requestEditors = append(c.requestEditors, editor)
+	}
+}
+
+func (c *ContextRestClient) editRequest(req *http.Request) error {
+	for _, editor := range c.requestEditors {
+		if err := editor(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// A StartSpan starts a tracing span for a request with the given method and
+// URL, returning a context carrying the span and a function to end it. It is
+// dependency-free, so it can be backed by OpenTelemetry or any other tracer.
+type StartSpan func(ctx context.Context, method, url string) (context.Context, EndSpan)
+
+// An EndSpan ends the span of a request, given the status code of its
+// response, or zero and the error if it failed without one.
+type EndSpan func(statusCode int, err error)
+
+// WithTracing makes the client start a span around every attempt at sending
+// a request. The request is sent with the context returned by start, so
+// request editors can propagate the trace, for example by injecting a
+// traceparent header.
+func WithTracing(start StartSpan) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.startSpan = start
+	}
+}
+
+// traceAttempt starts a span for an attempt at sending req, returning the
+// request to send in its place and the function ending the span.
+func (c *ContextRestClient) traceAttempt(req *http.Request) (*http.Request, EndSpan) {
+	if c.startSpan == nil {
+		return req, func(int, error) {}
+	}
+	ctx, end := c.startSpan(req.Context(), req.Method, req.URL.String())
+	return req.WithContext(ctx), end
+}
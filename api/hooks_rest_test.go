@@ -0,0 +1,120 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Request editors", func() {
+	ginkgo.It("run in order before each attempt", func() {
+		attempt := 0
+		var order []string
+		fake, server, client := newFakeContextServer(
+			WithRetries(2),
+			WithRequestEditor(func(req *http.Request) error {
+				attempt++
+				order = append(order, "first")
+				req.Header.Set("X-Attempt", strconv.Itoa(attempt))
+				return nil
+			}),
+			WithRequestEditor(func(req *http.Request) error {
+				order = append(order, "second")
+				req.Header.Set("X-Tenant", "tenant-"+req.Header.Get("X-Attempt"))
+				return nil
+			}),
+		)
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+
+		var tenants []string
+		fake.fail = func(req *http.Request) int {
+			tenants = append(tenants, req.Header.Get("X-Tenant"))
+			if len(tenants) == 1 {
+				return http.StatusServiceUnavailable
+			}
+			return 0
+		}
+
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(tenants).To(Equal([]string{"tenant-1", "tenant-2"}))
+		Expect(order).To(Equal([]string{"first", "second", "first", "second"}))
+	})
+
+	ginkgo.It("abort the request by returning an error", func() {
+		fake, server, client := newFakeContextServer(WithRequestEditor(func(req *http.Request) error {
+			return errors.New("no signing key")
+		}))
+		defer server.Close()
+
+		Expect(client.DeleteContext("context-id")).To(MatchError("no signing key"))
+		Expect(fake.Requests()).To(BeEmpty())
+	})
+})
+
+type traceIDKey struct{}
+
+type recordedSpan struct {
+	method     string
+	url        string
+	statusCode int
+	err        error
+}
+
+var _ = ginkgo.Describe("Tracing", func() {
+	ginkgo.It("starts and ends a span around each attempt", func() {
+		var spans []recordedSpan
+		fake, server, client := newFakeContextServer(
+			WithRetries(2),
+			WithTracing(func(ctx context.Context, method, url string) (context.Context, EndSpan) {
+				id := strconv.Itoa(len(spans) + 1)
+				return context.WithValue(ctx, traceIDKey{}, id), func(statusCode int, err error) {
+					spans = append(spans, recordedSpan{method: method, url: url, statusCode: statusCode, err: err})
+				}
+			}),
+			WithRequestEditor(func(req *http.Request) error {
+				req.Header.Set("traceparent", req.Context().Value(traceIDKey{}).(string))
+				return nil
+			}),
+		)
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+
+		var traceparents []string
+		fake.fail = func(req *http.Request) int {
+			traceparents = append(traceparents, req.Header.Get("traceparent"))
+			if len(traceparents) == 1 {
+				return http.StatusTooManyRequests
+			}
+			return 0
+		}
+
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(traceparents).To(Equal([]string{"1", "2"}))
+		Expect(spans).To(Equal([]recordedSpan{
+			{method: "DELETE", url: server.URL + "/api/v2/context/context-id", statusCode: http.StatusTooManyRequests},
+			{method: "DELETE", url: server.URL + "/api/v2/context/context-id", statusCode: http.StatusOK},
+		}))
+	})
+
+	ginkgo.It("ends the span with the error of a failed request", func() {
+		var spans []recordedSpan
+		_, server, client := newFakeContextServer(WithTracing(func(ctx context.Context, method, url string) (context.Context, EndSpan) {
+			return ctx, func(statusCode int, err error) {
+				spans = append(spans, recordedSpan{method: method, statusCode: statusCode, err: err})
+			}
+		}))
+		server.Close()
+
+		Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+		Expect(spans).To(HaveLen(1))
+		Expect(spans[0].statusCode).To(BeZero())
+		Expect(spans[0].err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ReportingWindow is the period of time the insights endpoints aggregate
+// metrics over, sent as their reporting-window parameter.
+type ReportingWindow string
+
+// The reporting windows accepted by the insights endpoints.
+const (
+	ReportingWindowLast24Hours ReportingWindow = "last-24-hours"
+	ReportingWindowLast7Days   ReportingWindow = "last-7-days"
+	ReportingWindowLast30Days  ReportingWindow = "last-30-days"
+	ReportingWindowLast60Days  ReportingWindow = "last-60-days"
+	ReportingWindowLast90Days  ReportingWindow = "last-90-days"
+)
+
+// ReportingWindows lists every valid ReportingWindow, shortest first.
+var ReportingWindows = []ReportingWindow{
+	ReportingWindowLast24Hours,
+	ReportingWindowLast7Days,
+	ReportingWindowLast30Days,
+	ReportingWindowLast60Days,
+	ReportingWindowLast90Days,
+}
+
+// ValidateReportingWindow checks that window is one of ReportingWindows, so
+// that a typo is reported clearly before any request is sent.
+func ValidateReportingWindow(window ReportingWindow) error {
+	names := make([]string, 0, len(ReportingWindows))
+	for _, valid := range ReportingWindows {
+		if window == valid {
+			return nil
+		}
+		names = append(names, string(valid))
+	}
+	return fmt.Errorf("Invalid reporting window '%s': expected one of %s", window, strings.Join(names, ", "))
+}
@@ -0,0 +1,25 @@
+package api
+
+// An Artifact is a file stored by a job, which can be downloaded from its URL
+// with DownloadArtifact.
+type Artifact struct {
+	Path string `json:"path"`
+	// NodeIndex is the index of the parallel run of the job which stored the
+	// artifact.
+	NodeIndex int    `json:"node_index"`
+	URL       string `json:"url"`
+}
+
+// A TestResult is the result of a single test run by a job, as reported by
+// its test metadata.
+type TestResult struct {
+	Name      string `json:"name"`
+	Classname string `json:"classname"`
+	// Result is the outcome of the test, such as "success", "failure" or
+	// "skipped".
+	Result string `json:"result"`
+	// RunTime is how long the test took to run, in seconds.
+	RunTime float64 `json:"run_time"`
+	// Message is the failure message of a test which didn't succeed.
+	Message string `json:"message"`
+}
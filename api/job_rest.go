@@ -0,0 +1,140 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+type listJobItemsParams struct {
+	ProjectSlug *string
+	JobNumber   int
+	// Endpoint is the job's list endpoint, such as "artifacts".
+	Endpoint  string
+	PageToken *string
+}
+
+type listJobItemsResponse[T any] struct {
+	Items         []T
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// ListJobArtifacts returns all of the artifacts stored by a job, identified by
+// its number within the project. Note that pagination is not currently
+// supported - we get all pages of artifacts and return them all. It returns a
+// NotFoundError if there is no such job.
+func (c *ContextRestClient) ListJobArtifacts(vcs, org, project string, jobNumber int, opts ...CallOption) (*[]Artifact, error) {
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	artifacts, err := listAllJobItems[Artifact](ctx, c, vcs, org, project, jobNumber, "artifacts")
+	return &artifacts, err
+}
+
+// GetJobTests returns the results of all of the tests run by a job, as stored
+// from its test metadata, identified by the job's number within the project.
+// Note that pagination is not currently supported - we get all pages of test
+// results and return them all. It returns a NotFoundError if there is no such
+// job.
+func (c *ContextRestClient) GetJobTests(vcs, org, project string, jobNumber int, opts ...CallOption) (*[]TestResult, error) {
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	tests, err := listAllJobItems[TestResult](ctx, c, vcs, org, project, jobNumber, "tests")
+	return &tests, err
+}
+
+// listAllJobItems fetches every page of one of a job's list endpoints.
+func listAllJobItems[T any](ctx context.Context, c *ContextRestClient, vcs, org, project string, jobNumber int, endpoint string) ([]T, error) {
+	slug := c.toProjectSlug(vcs, org, project)
+	params := &listJobItemsParams{
+		ProjectSlug: &slug,
+		JobNumber:   jobNumber,
+		Endpoint:    endpoint,
+	}
+	return paginate(ctx, nil, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]T, *string, error) {
+		params.PageToken = pageToken
+		resp, err := listJobItems[T](ctx, c, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+}
+
+func listJobItems[T any](ctx context.Context, c *ContextRestClient, params *listJobItemsParams) (*listJobItemsResponse[T], error) {
+	req, err := c.newListJobItemsRequest(params)
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find job %d in project '%s'", params.JobNumber, *params.ProjectSlug)}
+	}
+	var dest listJobItemsResponse[T]
+	resp, _, err := c.send(ctx, req, notFound, &dest)
+	if err != nil {
+		return nil, err
+	}
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newListJobItemsRequest(params *listJobItemsParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("project/%s/%d/%s", *params.ProjectSlug, params.JobNumber, params.Endpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	urlParams := url.Values{}
+	if params.PageToken != nil {
+		urlParams.Add("page-token", *params.PageToken)
+	}
+	queryURL.RawQuery = urlParams.Encode()
+
+	return c.newListRequest(queryURL.String())
+}
+
+// DownloadArtifact streams the contents of the artifact at artifactURL, as
+// listed by ListJobArtifacts, to w, without holding it all in memory. The
+// request carries the API token, since artifacts may be private, so only
+// artifact URLs listed by the API should be passed. Redirects to the storage
+// holding the artifact are followed without the token. Cancelling ctx aborts
+// the download. It returns a NotFoundError if there is no such artifact, and
+// an error for any other response which isn't successful.
+func (c *ContextRestClient) DownloadArtifact(ctx context.Context, artifactURL string, w io.Writer) error {
+	req, err := c.newHTTPRequest("GET", artifactURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "*/*")
+
+	resp, err := c.do(req.WithContext(withForeignRedirects(ctx)))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		bodyBytes, err := c.readBody(resp)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			return &NotFoundError{Message: fmt.Sprintf("Cannot find artifact '%s'", artifactURL)}
+		}
+		return newAPIError(resp, bodyBytes)
+	}
+	defer resp.Body.Close()
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Job REST client", func() {
+	ginkgo.Describe("ListJobArtifacts", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				if req.URL.Path != "/api/v2/project/gh/test-org/test-project/42/artifacts" {
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Job not found"}`))
+					return
+				}
+				switch req.URL.Query().Get("page-token") {
+				case "":
+					_, _ = rw.Write([]byte(`{"items": [{"path": "coverage/index.html", "node_index": 0, "url": "https://output.circle-artifacts.com/0/coverage/index.html"}], "next_page_token": "next"}`))
+				case "next":
+					_, _ = rw.Write([]byte(`{"items": [{"path": "logs/test.log", "node_index": 1, "url": "https://output.circle-artifacts.com/1/logs/test.log"}], "next_page_token": null}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the artifacts of every page", func() {
+			artifacts, err := newTestRestClient(server).ListJobArtifacts("gh", "test-org", "test-project", 42)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*artifacts).To(Equal([]Artifact{
+				{Path: "coverage/index.html", NodeIndex: 0, URL: "https://output.circle-artifacts.com/0/coverage/index.html"},
+				{Path: "logs/test.log", NodeIndex: 1, URL: "https://output.circle-artifacts.com/1/logs/test.log"},
+			}))
+		})
+
+		ginkgo.It("returns a NotFoundError for unknown jobs", func() {
+			_, err := newTestRestClient(server).ListJobArtifacts("gh", "test-org", "test-project", 7)
+			Expect(IsNotFoundError(err)).To(BeTrue())
+			Expect(err).To(MatchError("Cannot find job 7 in project 'gh/test-org/test-project'"))
+		})
+	})
+
+	ginkgo.Describe("GetJobTests", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if req.URL.Path != "/api/v2/project/gh/test-org/test-project/42/tests" {
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Job not found"}`))
+					return
+				}
+				switch req.URL.Query().Get("page-token") {
+				case "":
+					_, _ = rw.Write([]byte(`{"items": [{"name": "test_login", "classname": "tests.auth", "result": "success", "run_time": 0.25, "message": ""}], "next_page_token": "next"}`))
+				case "next":
+					_, _ = rw.Write([]byte(`{"items": [{"name": "test_logout", "classname": "tests.auth", "result": "failure", "run_time": 1.5, "message": "expected 200, got 500"}], "next_page_token": null}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the test results of every page", func() {
+			tests, err := newTestRestClient(server).GetJobTests("gh", "test-org", "test-project", 42)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*tests).To(Equal([]TestResult{
+				{Name: "test_login", Classname: "tests.auth", Result: "success", RunTime: 0.25},
+				{Name: "test_logout", Classname: "tests.auth", Result: "failure", RunTime: 1.5, Message: "expected 200, got 500"},
+			}))
+		})
+
+		ginkgo.It("returns a NotFoundError for unknown jobs", func() {
+			_, err := newTestRestClient(server).GetJobTests("gh", "test-org", "test-project", 7)
+			Expect(IsNotFoundError(err)).To(BeTrue())
+			Expect(err).To(MatchError("Cannot find job 7 in project 'gh/test-org/test-project'"))
+		})
+	})
+
+	ginkgo.Describe("DownloadArtifact", func() {
+		var (
+			server  *httptest.Server
+			storage *httptest.Server
+			tokens  []string
+		)
+
+		ginkgo.BeforeEach(func() {
+			tokens = nil
+			storage = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				tokens = append(tokens, req.Header.Get("circle-token"))
+				_, _ = rw.Write([]byte("stored bytes"))
+			}))
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/0/coverage.txt":
+					tokens = append(tokens, req.Header.Get("circle-token"))
+					_, _ = rw.Write([]byte("artifact bytes"))
+				case "/0/stored.txt":
+					tokens = append(tokens, req.Header.Get("circle-token"))
+					http.Redirect(rw, req, storage.URL+"/signed", http.StatusFound)
+				case "/0/forbidden.txt":
+					rw.WriteHeader(http.StatusForbidden)
+				default:
+					rw.WriteHeader(http.StatusNotFound)
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+			storage.Close()
+		})
+
+		ginkgo.It("streams the artifact to the writer with the token", func() {
+			var buf bytes.Buffer
+			err := newTestRestClient(server).DownloadArtifact(context.Background(), server.URL+"/0/coverage.txt", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("artifact bytes"))
+			Expect(tokens).To(Equal([]string{"token"}))
+		})
+
+		ginkgo.It("follows redirects to storage without the token", func() {
+			var buf bytes.Buffer
+			err := newTestRestClient(server).DownloadArtifact(context.Background(), server.URL+"/0/stored.txt", &buf)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(buf.String()).To(Equal("stored bytes"))
+			Expect(tokens).To(Equal([]string{"token", ""}))
+		})
+
+		ginkgo.It("returns errors for unsuccessful responses", func() {
+			client := newTestRestClient(server)
+			var buf bytes.Buffer
+			err := client.DownloadArtifact(context.Background(), server.URL+"/0/forbidden.txt", &buf)
+			var apiErr *APIError
+			Expect(errors.As(err, &apiErr)).To(BeTrue())
+			Expect(apiErr.StatusCode).To(Equal(http.StatusForbidden))
+
+			err = client.DownloadArtifact(context.Background(), server.URL+"/0/missing.txt", &buf)
+			Expect(IsNotFoundError(err)).To(BeTrue())
+			Expect(buf.Len()).To(BeZero())
+		})
+
+		ginkgo.It("stops when its context is cancelled", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			var buf bytes.Buffer
+			err := newTestRestClient(server).DownloadArtifact(ctx, server.URL+"/0/coverage.txt", &buf)
+			Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+			Expect(tokens).To(BeEmpty())
+		})
+	})
+})
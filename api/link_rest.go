@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// nextPageToken returns the token of the page after the one in resp. It is
+// token, the next_page_token of the response body, if that is set. Otherwise,
+// if resp has an RFC 5988 Link header with rel="next", as some proxies in
+// front of the API send instead, it is the absolute URL of that link, which
+// the list request builders follow as is (see pageLink). Links to other hosts
+// are refused, since following them would send the API token there.
+func (c *ContextRestClient) nextPageToken(resp *http.Response, token *string) (*string, error) {
+	if token != nil {
+		return token, nil
+	}
+	link, ok := nextLink(resp.Header.Values("Link"))
+	if !ok {
+		return nil, nil
+	}
+	base, err := url.Parse(c.server)
+	if resp.Request != nil {
+		base, err = resp.Request.URL, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	next, err := base.Parse(link)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid next page link '%s': %w", link, err)
+	}
+	if !c.onServer(next) {
+		return nil, fmt.Errorf("Refusing to follow the next page link to %s, which is not on the API's host", next.Redacted())
+	}
+	nextURL := next.String()
+	return &nextURL, nil
+}
+
+// pageLink reports whether pageToken is the URL of a next page link returned
+// by nextPageToken, rather than a next_page_token, and if so returns it.
+func (c *ContextRestClient) pageLink(pageToken *string) (string, bool) {
+	if pageToken == nil {
+		return "", false
+	}
+	link, err := url.Parse(*pageToken)
+	if err != nil || !link.IsAbs() || !c.onServer(link) {
+		return "", false
+	}
+	return *pageToken, true
+}
+
+// onServer reports whether u has the scheme and host of the API.
+func (c *ContextRestClient) onServer(u *url.URL) bool {
+	server, err := url.Parse(c.server)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, server.Scheme) && strings.EqualFold(u.Host, server.Host)
+}
+
+// nextLink finds the target of the link with rel="next" in the values of a
+// Link header, such as `<https://host/items?page=2>; rel="next"`.
+func nextLink(values []string) (string, bool) {
+	for _, value := range values {
+		for _, link := range strings.Split(value, ",") {
+			parts := strings.Split(link, ";")
+			target := strings.TrimSpace(parts[0])
+			if !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, param := range parts[1:] {
+				name, rel, found := strings.Cut(strings.TrimSpace(param), "=")
+				if !found || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+					continue
+				}
+				for _, rel := range strings.Fields(strings.Trim(strings.TrimSpace(rel), `"`)) {
+					if strings.EqualFold(rel, "next") {
+						return strings.TrimSuffix(strings.TrimPrefix(target, "<"), ">"), true
+					}
+				}
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Link header pagination", func() {
+	var (
+		server   *httptest.Server
+		client   *ContextRestClient
+		requests []string
+		// respond writes the page of contexts numbered page.
+		respond func(rw http.ResponseWriter, page int)
+	)
+
+	ginkgo.BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			requests = append(requests, req.URL.RequestURI())
+			page := 1
+			if p := req.URL.Query().Get("page"); p != "" {
+				page, _ = strconv.Atoi(p)
+			}
+			respond(rw, page)
+		}))
+		client = newTestRestClient(server)
+		respond = func(rw http.ResponseWriter, page int) {
+			if page < 3 {
+				rw.Header().Add("Link", fmt.Sprintf(`</api/v2/context?page=1>; rel="first", </api/v2/context?owner-slug=gh%%2Ftest-org&page=%d>; rel="next"`, page+1))
+			}
+			_, _ = fmt.Fprintf(rw, `{"items": [{"id": "id-%d", "name": "ctx-%d"}]}`, page, page)
+		}
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("follows next links", func() {
+		contexts, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		var names []string
+		for _, context := range *contexts {
+			names = append(names, context.Name)
+		}
+		Expect(names).To(Equal([]string{"ctx-1", "ctx-2", "ctx-3"}))
+		Expect(requests).To(Equal([]string{
+			"/api/v2/context?owner-slug=gh%2Ftest-org",
+			"/api/v2/context?owner-slug=gh%2Ftest-org&page=2",
+			"/api/v2/context?owner-slug=gh%2Ftest-org&page=3",
+		}))
+	})
+
+	ginkgo.It("prefers next_page_token over the Link header", func() {
+		respond = func(rw http.ResponseWriter, page int) {
+			if len(requests) == 1 {
+				rw.Header().Add("Link", `</api/v2/context?page=2>; rel="next"`)
+				_, _ = rw.Write([]byte(`{"items": [{"id": "id-1", "name": "ctx-1"}], "next_page_token": "token"}`))
+				return
+			}
+			_, _ = rw.Write([]byte(`{"items": [{"id": "id-2", "name": "ctx-2"}]}`))
+		}
+
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(Equal([]string{
+			"/api/v2/context?owner-slug=gh%2Ftest-org",
+			"/api/v2/context?owner-slug=gh%2Ftest-org&page-token=token",
+		}))
+	})
+
+	ginkgo.It("refuses to follow links to other hosts", func() {
+		respond = func(rw http.ResponseWriter, page int) {
+			rw.Header().Add("Link", `<https://elsewhere.example/context?page=2>; rel="next"`)
+			_, _ = rw.Write([]byte(`{"items": []}`))
+		}
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).To(MatchError("Refusing to follow the next page link to https://elsewhere.example/context?page=2, which is not on the API's host"))
+		Expect(requests).To(HaveLen(1))
+	})
+
+	ginkgo.Describe("nextLink", func() {
+		ginkgo.It("finds the next link among others", func() {
+			link, ok := nextLink([]string{`<https://host/a?page=1>; rel="prev"`, `<https://host/a?page=3>; title="x"; rel="last next"`})
+			Expect(ok).To(BeTrue())
+			Expect(link).To(Equal("https://host/a?page=3"))
+		})
+
+		ginkgo.It("reports when there is no next link", func() {
+			_, ok := nextLink([]string{`<https://host/a?page=1>; rel="prev"`})
+			Expect(ok).To(BeFalse())
+			_, ok = nextLink(nil)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
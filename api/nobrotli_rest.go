@@ -0,0 +1,13 @@
+//go:build !brotli
+
+package api
+
+import "errors"
+
+// errBrotliUnsupported is returned for Brotli-encoded responses by builds
+// without the brotli tag, which leave out the decoder.
+var errBrotliUnsupported = errors.New("The response is Brotli-encoded, but this build can't decode Brotli; build with -tags brotli to support it")
+
+func decodeBrotli(bodyBytes []byte) ([]byte, error) {
+	return nil, errBrotliUnsupported
+}
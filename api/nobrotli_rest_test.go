@@ -0,0 +1,25 @@
+//go:build !brotli
+
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Brotli responses without the brotli tag", func() {
+	ginkgo.It("explains that Brotli isn't supported", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Encoding", "br")
+			_, _ = rw.Write([]byte{0x0b, 0x02, 0x80})
+		}))
+		defer server.Close()
+
+		_, err := newTestRestClient(server).GetContextByID("context-id")
+		Expect(err).To(Equal(errBrotliUnsupported))
+	})
+})
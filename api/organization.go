@@ -0,0 +1,19 @@
+package api
+
+// An Organization is a VCS organization or user account that has been set up
+// to build on CircleCI.
+type Organization struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	VCSType string `json:"vcs_type"`
+}
+
+// A Collaboration is an organization the authenticated user is a member of.
+type Collaboration struct {
+	ID        string `json:"id"`
+	VCSType   string `json:"vcs-type"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url"`
+	Slug      string `json:"slug"`
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// GetOrganization returns the given org. For GitLab organizations (vcs
+// "gitlab" or "circleci"), org must be the organization ID. The API does not
+// currently return the org's plan or limits, such as its maximum number of
+// contexts.
+func (c *ContextRestClient) GetOrganization(vcs, org string, opts ...CallOption) (*Organization, error) {
+	req, err := c.newGetOrganizationRequest(vcs, org)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	var dest Organization
+	if _, _, err := c.send(ctx, req, nil, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newGetOrganizationRequest(vcs, org string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+
+	owner := c.ownerParams(vcs, org)
+	slugOrID := owner.OwnerID
+	if slugOrID == nil {
+		slugOrID = owner.OwnerSlug
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("organization/%s", *slugOrID))
+	if err != nil {
+		return nil, err
+	}
+
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
+}
+
+// ResolveOwnerID returns the ID of the given org, which endpoints taking an
+// owner-id need, by finding it among the collaborations of the authenticated
+// user. For GitLab organizations, org must already be the organization ID, and
+// is returned as is. The IDs are cached by the client, so that only the first
+// lookup lists the collaborations. It returns a NotFoundError if the user is
+// not a member of the org.
+func (c *ContextRestClient) ResolveOwnerID(vcs, org string, opts ...CallOption) (string, error) {
+	if isGitLab(vcs) {
+		return org, nil
+	}
+	if id, ok := c.ownerIDs.get(vcs, org); ok {
+		return id, nil
+	}
+
+	collaborations, err := c.collaborations(opts)
+	if err != nil {
+		return "", err
+	}
+	for _, collaboration := range collaborations {
+		c.ownerIDs.put(collaboration)
+	}
+	if id, ok := c.ownerIDs.get(vcs, org); ok {
+		return id, nil
+	}
+	return "", &NotFoundError{Message: fmt.Sprintf("Cannot find organization '%s' among the collaborations of the user", *c.toSlug(vcs, org))}
+}
+
+// collaborations returns the organizations the authenticated user is a member
+// of.
+func (c *ContextRestClient) collaborations(opts []CallOption) ([]Collaboration, error) {
+	req, err := c.newCollaborationsRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	var dest []Collaboration
+	if _, _, err := c.send(ctx, req, nil, &dest); err != nil {
+		return nil, err
+	}
+	return dest, nil
+}
+
+func (c *ContextRestClient) newCollaborationsRequest() (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse("me/collaborations")
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
+}
+
+// ownerIDCache maps org slugs to their IDs. It is safe for concurrent use.
+type ownerIDCache struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+// vcsSlugPrefixes maps the vcs types of collaborations to the prefixes of
+// their slugs.
+var vcsSlugPrefixes = map[string]string{
+	"github":    "gh",
+	"bitbucket": "bb",
+}
+
+// ownerIDKey normalizes the vcs and org of a slug, so that "github/Org" and
+// "gh/org" share an entry.
+func ownerIDKey(vcs, org string) string {
+	vcs = strings.ToLower(vcs)
+	if prefix, ok := vcsSlugPrefixes[vcs]; ok {
+		vcs = prefix
+	}
+	return vcs + "/" + strings.ToLower(org)
+}
+
+func (o *ownerIDCache) get(vcs, org string) (string, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	id, ok := o.ids[ownerIDKey(vcs, org)]
+	return id, ok
+}
+
+func (o *ownerIDCache) put(collaboration Collaboration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.ids == nil {
+		o.ids = map[string]string{}
+	}
+	o.ids[ownerIDKey(collaboration.VCSType, collaboration.Name)] = collaboration.ID
+}
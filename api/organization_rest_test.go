@@ -0,0 +1,123 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("GetOrganization", func() {
+	var (
+		server *httptest.Server
+		path   string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			path = req.URL.Path
+			if req.URL.Path == "/api/v2/organization/gh/missing-org" {
+				rw.WriteHeader(http.StatusNotFound)
+				_, _ = rw.Write([]byte(`{"message": "Organization not found."}`))
+				return
+			}
+			_, _ = rw.Write([]byte(`{
+				"id": "org-id",
+				"name": "test-org",
+				"slug": "gh/test-org",
+				"vcs_type": "github"
+			}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("returns the organization", func() {
+		client := newTestRestClient(server)
+		org, err := client.GetOrganization("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal("/api/v2/organization/gh/test-org"))
+		Expect(*org).To(Equal(Organization{
+			ID:      "org-id",
+			Name:    "test-org",
+			Slug:    "gh/test-org",
+			VCSType: "github",
+		}))
+	})
+
+	ginkgo.It("addresses GitLab organizations by ID", func() {
+		client := newTestRestClient(server)
+		_, err := client.GetOrganization("gitlab", "org-id")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(path).To(Equal("/api/v2/organization/org-id"))
+	})
+
+	ginkgo.It("returns API errors", func() {
+		client := newTestRestClient(server)
+		_, err := client.GetOrganization("gh", "missing-org")
+		Expect(err).To(MatchError("Organization not found."))
+	})
+})
+
+var _ = ginkgo.Describe("ResolveOwnerID", func() {
+	var (
+		server   *httptest.Server
+		client   *ContextRestClient
+		requests int
+	)
+
+	ginkgo.BeforeEach(func() {
+		requests = 0
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			defer ginkgo.GinkgoRecover()
+			requests++
+			Expect(req.URL.Path).To(Equal("/api/v2/me/collaborations"))
+			_, _ = rw.Write([]byte(`[
+				{"id": "org-uuid", "vcs-type": "github", "name": "test-org", "avatar_url": "https://example.com/a.png", "slug": "gh/test-org"},
+				{"id": "other-uuid", "vcs-type": "bitbucket", "name": "Other-Org", "avatar_url": "", "slug": "bb/Other-Org"}
+			]`))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("finds the org among the user's collaborations", func() {
+		id, err := client.ResolveOwnerID("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal("org-uuid"))
+
+		id, err = client.ResolveOwnerID("bitbucket", "other-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal("other-uuid"))
+	})
+
+	ginkgo.It("caches the IDs", func() {
+		for i := 0; i < 3; i++ {
+			_, err := client.ResolveOwnerID("github", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		_, err := client.ResolveOwnerID("bb", "Other-Org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requests).To(Equal(1))
+	})
+
+	ginkgo.It("returns a NotFoundError for orgs the user isn't a member of", func() {
+		_, err := client.ResolveOwnerID("gh", "missing-org")
+		Expect(IsNotFoundError(err)).To(BeTrue())
+		Expect(err).To(MatchError("Cannot find organization 'gh/missing-org' among the collaborations of the user"))
+	})
+
+	ginkgo.It("returns GitLab organization IDs as they are", func() {
+		id, err := client.ResolveOwnerID("gitlab", "gitlab-uuid")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(id).To(Equal("gitlab-uuid"))
+		Expect(requests).To(BeZero())
+	})
+})
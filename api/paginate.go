@@ -0,0 +1,199 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// A pageFetcher fetches the page of items identified by pageToken, where a nil
+// pageToken identifies the first page. It returns the items along with the
+// token of the next page, which is nil on the last page.
+type pageFetcher[T any] func(ctx context.Context, pageToken *string) ([]T, *string, error)
+
+// A rawItem is an item of a list along with its JSON, exactly as the server
+// sent it.
+type rawItem[T any] struct {
+	item T
+	raw  json.RawMessage
+}
+
+// withRaw pairs each of the items of a page with its raw JSON.
+func withRaw[T any](items []T, raw []json.RawMessage) []rawItem[T] {
+	paired := make([]rawItem[T], len(items))
+	for i := range items {
+		paired[i] = rawItem[T]{item: items[i], raw: raw[i]}
+	}
+	return paired
+}
+
+// A Page is a single page of a list, along with the tokens identifying it and
+// the page after it, for callers which page through a list themselves.
+type Page[T any] struct {
+	Items []T
+	// PageToken identifies this page. It is empty for the first page.
+	PageToken string
+	// NextPageToken identifies the next page. It is empty on the last page.
+	NextPageToken string
+}
+
+// Len returns how many items the page holds.
+func (p *Page[T]) Len() int {
+	return len(p.Items)
+}
+
+// fetchPage fetches the single page identified by pageToken, where an empty
+// pageToken identifies the first page.
+func fetchPage[T any](ctx context.Context, pageToken string, fetch pageFetcher[T]) (*Page[T], error) {
+	var token *string
+	if pageToken != "" {
+		token = &pageToken
+	}
+	items, next, err := fetch(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	page := &Page[T]{Items: items, PageToken: pageToken}
+	if page.Items == nil {
+		page.Items = []T{}
+	}
+	if next != nil {
+		page.NextPageToken = *next
+	}
+	return page, nil
+}
+
+// A PageCallback is told about each page fetched by a list method: its
+// number, counting from 1, and how many items it held.
+type PageCallback func(page, items int)
+
+// WithPageCallback makes the client call callback after fetching each page of
+// a list, which can be used to report progress on long listings. The callback
+// runs in the fetching loop, so it should return quickly, and batch
+// operations may call it concurrently, for each of the lists they fetch.
+func WithPageCallback(callback PageCallback) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.pageCallback = callback
+	}
+}
+
+// reportPages wraps fetch so that callback, if not nil, is called after each
+// page is successfully fetched.
+func reportPages[T any](callback PageCallback, fetch pageFetcher[T]) pageFetcher[T] {
+	if callback == nil {
+		return fetch
+	}
+	page := 0
+	return func(ctx context.Context, pageToken *string) ([]T, *string, error) {
+		items, next, err := fetch(ctx, pageToken)
+		if err == nil {
+			page++
+			callback(page, len(items))
+		}
+		return items, next, err
+	}
+}
+
+// paginate fetches every page of a list endpoint, one after the other,
+// starting from the page identified by pageToken. It stops early if ctx is
+// cancelled, or with an error after maxPages pages unless maxPages is zero.
+func paginate[T any](ctx context.Context, pageToken *string, maxPages int, fetch pageFetcher[T]) ([]T, error) {
+	var items []T
+	err := walkPages(ctx, pageToken, maxPages, fetch, func(pageItems []T) {
+		items = append(items, pageItems...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// countItems fetches every page like paginate, but only counts the items,
+// without keeping them.
+func countItems[T any](ctx context.Context, pageToken *string, maxPages int, fetch pageFetcher[T]) (int, error) {
+	count := 0
+	err := walkPages(ctx, pageToken, maxPages, fetch, func(pageItems []T) {
+		count += len(pageItems)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// walkPages fetches the pages of a list endpoint as described by paginate,
+// passing the items of each to visit.
+func walkPages[T any](ctx context.Context, pageToken *string, maxPages int, fetch pageFetcher[T], visit func([]T)) error {
+	for page := 1; ; page++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if maxPages > 0 && page > maxPages {
+			return fmt.Errorf("Gave up listing after %d pages", maxPages)
+		}
+
+		pageItems, next, err := fetch(ctx, pageToken)
+		if err != nil {
+			return err
+		}
+
+		visit(pageItems)
+
+		if next == nil {
+			return nil
+		}
+
+		pageToken = next
+	}
+}
+
+// A pageResult is either a page of items fetched by prefetchPages, or the
+// error which ended the listing.
+type pageResult[T any] struct {
+	items []T
+	err   error
+}
+
+// prefetchPages fetches pages like paginate, but in the background, keeping
+// up to buffer pages ready ahead of the consumer so that processing one page
+// overlaps with fetching the next. The requests themselves are still made one
+// after the other, since each needs the token from the previous response, so
+// this only helps when the consumer does significant work per page, and costs
+// up to buffer pages of memory. The channel is closed after the last page or
+// the first error; cancel ctx to stop early.
+func prefetchPages[T any](ctx context.Context, pageToken *string, maxPages, buffer int, fetch pageFetcher[T]) <-chan pageResult[T] {
+	results := make(chan pageResult[T], buffer)
+	go func() {
+		defer close(results)
+		send := func(result pageResult[T]) bool {
+			select {
+			case results <- result:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		for page := 1; ; page++ {
+			if err := ctx.Err(); err != nil {
+				send(pageResult[T]{err: err})
+				return
+			}
+			if maxPages > 0 && page > maxPages {
+				send(pageResult[T]{err: fmt.Errorf("Gave up listing after %d pages", maxPages)})
+				return
+			}
+
+			items, next, err := fetch(ctx, pageToken)
+			if err != nil {
+				send(pageResult[T]{err: err})
+				return
+			}
+			if !send(pageResult[T]{items: items}) || next == nil {
+				return
+			}
+			pageToken = next
+		}
+	}()
+	return results
+}
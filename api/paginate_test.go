@@ -0,0 +1,189 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakePages serves the given pages, with page tokens being page indexes.
+func fakePages(pages [][]int, requested *[]string) pageFetcher[int] {
+	return func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+		page := 0
+		if pageToken != nil {
+			page, _ = strconv.Atoi(*pageToken)
+		}
+		*requested = append(*requested, strconv.Itoa(page))
+		var next *string
+		if page+1 < len(pages) {
+			token := strconv.Itoa(page + 1)
+			next = &token
+		}
+		return pages[page], next, nil
+	}
+}
+
+var _ = ginkgo.Describe("paginate", func() {
+	pages := [][]int{{1, 2}, {3}, {}, {4, 5}}
+
+	ginkgo.It("fetches every page in order", func() {
+		var requested []string
+		items, err := paginate(context.Background(), nil, 0, fakePages(pages, &requested))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]int{1, 2, 3, 4, 5}))
+		Expect(requested).To(Equal([]string{"0", "1", "2", "3"}))
+	})
+
+	ginkgo.It("starts from the given page token", func() {
+		var requested []string
+		token := "2"
+		items, err := paginate(context.Background(), &token, 0, fakePages(pages, &requested))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]int{4, 5}))
+		Expect(requested).To(Equal([]string{"2", "3"}))
+	})
+
+	ginkgo.It("gives up after the maximum number of pages", func() {
+		var requested []string
+		_, err := paginate(context.Background(), nil, 2, fakePages(pages, &requested))
+		Expect(err).To(MatchError("Gave up listing after 2 pages"))
+		Expect(requested).To(HaveLen(2))
+	})
+
+	ginkgo.It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		var requested []string
+		fetch := fakePages(pages, &requested)
+		_, err := paginate(ctx, nil, 0, func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+			cancel()
+			return fetch(ctx, pageToken)
+		})
+		Expect(err).To(Equal(context.Canceled))
+		Expect(requested).To(HaveLen(1))
+	})
+
+	ginkgo.It("returns the fetcher's errors", func() {
+		_, err := paginate(context.Background(), nil, 0, func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+			return nil, nil, errors.New("boom")
+		})
+		Expect(err).To(MatchError("boom"))
+	})
+})
+
+var _ = ginkgo.Describe("countItems", func() {
+	ginkgo.It("counts the items of every page", func() {
+		var requested []string
+		count, err := countItems(context.Background(), nil, 0, fakePages([][]int{{1, 2}, {3}, {}, {4, 5}}, &requested))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(count).To(Equal(5))
+		Expect(requested).To(Equal([]string{"0", "1", "2", "3"}))
+	})
+
+	ginkgo.It("returns the fetcher's errors", func() {
+		_, err := countItems(context.Background(), nil, 0, func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+			return nil, nil, errors.New("boom")
+		})
+		Expect(err).To(MatchError("boom"))
+	})
+})
+
+// collectPages drains the channel returned by prefetchPages.
+func collectPages(results <-chan pageResult[int]) ([]int, error) {
+	var items []int
+	for result := range results {
+		if result.err != nil {
+			return items, result.err
+		}
+		items = append(items, result.items...)
+	}
+	return items, nil
+}
+
+var _ = ginkgo.Describe("prefetchPages", func() {
+	pages := [][]int{{1, 2}, {3}, {}, {4, 5}}
+
+	ginkgo.It("delivers every page in order", func() {
+		var requested []string
+		items, err := collectPages(prefetchPages(context.Background(), nil, 0, 2, fakePages(pages, &requested)))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(items).To(Equal([]int{1, 2, 3, 4, 5}))
+		Expect(requested).To(Equal([]string{"0", "1", "2", "3"}))
+	})
+
+	ginkgo.It("fetches ahead of the consumer, up to the buffer size", func() {
+		var requested []string
+		results := prefetchPages(context.Background(), nil, 0, 1, fakePages(pages, &requested))
+		first := <-results
+		Expect(first.items).To(Equal([]int{1, 2}))
+		// One page is buffered and the goroutine is blocked sending another.
+		Eventually(func() int { return len(results) }).Should(Equal(1))
+		_, err := collectPages(results)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	ginkgo.It("delivers the error which ended the listing", func() {
+		results := prefetchPages(context.Background(), nil, 0, 2, func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+			return nil, nil, errors.New("boom")
+		})
+		_, err := collectPages(results)
+		Expect(err).To(MatchError("boom"))
+	})
+
+	ginkgo.It("stops fetching when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		var requested []string
+		results := prefetchPages(ctx, nil, 0, 0, fakePages(pages, &requested))
+		<-results
+		cancel()
+		Eventually(func() bool {
+			_, open := <-results
+			return open
+		}).Should(BeFalse())
+		Expect(len(requested)).To(BeNumerically("<=", 2))
+	})
+})
+
+// slowPages serves n pages of one item each, taking pageDelay to fetch each.
+func slowPages(n int, pageDelay time.Duration) pageFetcher[int] {
+	return func(ctx context.Context, pageToken *string) ([]int, *string, error) {
+		page := 0
+		if pageToken != nil {
+			page, _ = strconv.Atoi(*pageToken)
+		}
+		time.Sleep(pageDelay)
+		var next *string
+		if page+1 < n {
+			token := strconv.Itoa(page + 1)
+			next = &token
+		}
+		return []int{page}, next, nil
+	}
+}
+
+// The benchmarks list 10 pages which each take 1ms to fetch and 1ms to
+// process: prefetching should take roughly half as long.
+
+func BenchmarkPaginate(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		items, _ := paginate(context.Background(), nil, 0, slowPages(10, time.Millisecond))
+		for range items {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func BenchmarkPrefetchPages(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for page := range prefetchPages(context.Background(), nil, 0, 2, slowPages(10, time.Millisecond)) {
+			for range page.items {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+// A Pipeline is a single run of a project's configuration, triggered by a
+// push, an API call or a schedule.
+type Pipeline struct {
+	ID          string    `json:"id"`
+	Number      int       `json:"number"`
+	ProjectSlug string    `json:"project_slug"`
+	State       string    `json:"state"`
+	CreatedAt   time.Time `json:"created_at"`
+	VCS         struct {
+		Branch   string `json:"branch"`
+		Tag      string `json:"tag"`
+		Revision string `json:"revision"`
+	} `json:"vcs"`
+}
+
+// A PipelineConfig is the configuration a Pipeline ran, both as written and
+// as compiled by CircleCI. The setup fields are only set for pipelines using
+// dynamic configuration.
+type PipelineConfig struct {
+	Source              string `json:"source"`
+	Compiled            string `json:"compiled"`
+	SetupConfig         string `json:"setup-config,omitempty"`
+	CompiledSetupConfig string `json:"compiled-setup-config,omitempty"`
+}
+
+// A PipelineParameterValidator checks a single pipeline parameter before it is
+// sent to CircleCI. It returns an error describing why the value is rejected.
+type PipelineParameterValidator func(name string, value interface{}) error
+
+// ValidatePipelineParameter is the default PipelineParameterValidator. CircleCI
+// only accepts strings, numbers and booleans as pipeline parameter values.
+func ValidatePipelineParameter(name string, value interface{}) error {
+	switch value.(type) {
+	case string, bool,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64,
+		float32, float64:
+		return nil
+	default:
+		return fmt.Errorf("Invalid value for pipeline parameter '%s': expected a string, number or boolean, got %T", name, value)
+	}
+}
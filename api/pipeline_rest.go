@@ -0,0 +1,300 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+type listPipelinesParams struct {
+	ProjectSlug *string
+	OrgSlug     *string
+	Mine        bool
+	Branch      *string
+	Tag         *string
+	PageToken   *string
+}
+
+// A PipelineFilter narrows the pipelines ListPipelinesForProject returns. The
+// filters are applied by the API, and at most one of them may be set.
+type PipelineFilter struct {
+	// Branch selects the pipelines of a branch.
+	Branch string
+	// Tag selects the pipelines of a tag.
+	Tag string
+}
+
+type listPipelinesResponse struct {
+	Items         []Pipeline
+	NextPageToken *string `json:"next_page_token"`
+}
+
+func (c *ContextRestClient) toProjectSlug(vcs, org, project string) string {
+	return fmt.Sprintf("%s/%s", *c.toSlug(vcs, org), project)
+}
+
+// TriggerPipeline triggers a new pipeline on the given branch of a project.
+// Each parameter is checked by the client's PipelineParameterValidator before
+// the request is sent.
+func (c *ContextRestClient) TriggerPipeline(vcs, org, project, branch string, parameters map[string]interface{}, opts ...CallOption) (*Pipeline, error) {
+	if c.validateParameter != nil {
+		for name, value := range parameters {
+			if err := c.validateParameter(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	req, err := c.newTriggerPipelineRequest(vcs, org, project, branch, parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	var dest Pipeline
+	if _, _, err := c.send(ctx, req, nil, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newTriggerPipelineRequest(vcs, org, project, branch string, parameters map[string]interface{}) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("project/%s/pipeline", c.toProjectSlug(vcs, org, project)))
+	if err != nil {
+		return nil, err
+	}
+
+	body := struct {
+		Branch     string                 `json:"branch,omitempty"`
+		Parameters map[string]interface{} `json:"parameters,omitempty"`
+	}{
+		Branch:     branch,
+		Parameters: parameters,
+	}
+	return c.newJSONRequest("POST", queryURL.String(), body)
+}
+
+// ErrPipelineConfigPending is returned by GetPipelineConfig when the
+// pipeline's configuration hasn't been compiled yet.
+var ErrPipelineConfigPending = errors.New("The pipeline's configuration is not available yet, as the pipeline has not been compiled")
+
+// GetPipelineConfig returns the configuration the pipeline with the given ID
+// ran. It returns ErrPipelineConfigPending if the pipeline is still pending,
+// and a NotFoundError if there is no such pipeline.
+func (c *ContextRestClient) GetPipelineConfig(pipelineID string, opts ...CallOption) (*PipelineConfig, error) {
+	req, err := c.newGetPipelineConfigRequest(pipelineID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find pipeline with ID '%s'", pipelineID)}
+	}
+	var dest PipelineConfig
+	_, _, err = c.send(ctx, req, notFound, &dest)
+	if err != nil && err != ErrEmptyResponseBody {
+		return nil, err
+	}
+	if dest.Compiled == "" {
+		return nil, ErrPipelineConfigPending
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newGetPipelineConfigRequest(pipelineID string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("pipeline/%s/config", pipelineID))
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
+}
+
+// ListPipelinesForProject returns all of the pipelines of a project which
+// match filter, most recent first. It returns an error if filter sets both a
+// branch and a tag. Note that pagination is not currently supported - we get
+// all pages of pipelines and return them all.
+func (c *ContextRestClient) ListPipelinesForProject(vcs, org, project string, filter PipelineFilter, opts ...CallOption) (*[]Pipeline, error) {
+	if filter.Branch != "" && filter.Tag != "" {
+		return nil, errors.New("Cannot filter pipelines by both a branch and a tag")
+	}
+
+	slug := c.toProjectSlug(vcs, org, project)
+	params := &listPipelinesParams{
+		ProjectSlug: &slug,
+	}
+	if filter.Branch != "" {
+		params.Branch = &filter.Branch
+	}
+	if filter.Tag != "" {
+		params.Tag = &filter.Tag
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	pipelines, err := c.listAllPipelines(ctx, params)
+	return &pipelines, err
+}
+
+// LatestPipeline returns the most recently created pipeline on the given
+// branch of a project. It returns a NotFoundError if the branch has no
+// pipelines. Since pipelines are listed newest first, only the first page is
+// fetched.
+func (c *ContextRestClient) LatestPipeline(vcs, org, project, branch string, opts ...CallOption) (*Pipeline, error) {
+	slug := c.toProjectSlug(vcs, org, project)
+	params := &listPipelinesParams{
+		ProjectSlug: &slug,
+		Branch:      &branch,
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	resp, err := c.listPipelines(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var latest *Pipeline
+	for i, pipeline := range resp.Items {
+		if latest == nil || pipeline.CreatedAt.After(latest.CreatedAt) {
+			latest = &resp.Items[i]
+		}
+	}
+	if latest == nil {
+		return nil, &NotFoundError{Message: fmt.Sprintf("Cannot find a pipeline on branch '%s'", branch)}
+	}
+	return latest, nil
+}
+
+// ListPipelinesForOrg returns the most recent pipelines of every project in an
+// org, newest first, without having to list each project. At most limit
+// pipelines are returned, fetching only as many pages as are needed; a limit
+// of zero fetches the org's entire pipeline history. If mine is true, only
+// the pipelines triggered by the caller are returned.
+func (c *ContextRestClient) ListPipelinesForOrg(vcs, org string, mine bool, limit int, opts ...CallOption) (*[]Pipeline, error) {
+	params := &listPipelinesParams{
+		OrgSlug: c.toSlug(vcs, org),
+		Mine:    mine,
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	pipelines, err := c.listPipelinesUpTo(ctx, params, limit)
+	return &pipelines, err
+}
+
+// MyPipelines returns the pipelines triggered by the caller, across all of
+// their orgs, newest first, fetching every page.
+func (c *ContextRestClient) MyPipelines(opts ...CallOption) (*[]Pipeline, error) {
+	params := &listPipelinesParams{
+		Mine: true,
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	pipelines, err := c.listPipelinesUpTo(ctx, params, 0)
+	return &pipelines, err
+}
+
+// listPipelinesUpTo lists the pipelines selected by params, fetching only as
+// many pages as are needed for limit pipelines, unless limit is zero.
+func (c *ContextRestClient) listPipelinesUpTo(ctx context.Context, params *listPipelinesParams, limit int) ([]Pipeline, error) {
+	fetched := 0
+	pipelines, err := paginate(ctx, nil, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]Pipeline, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listPipelines(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		fetched += len(resp.Items)
+		if limit > 0 && fetched >= limit {
+			return resp.Items, nil, nil
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+	if limit > 0 && len(pipelines) > limit {
+		pipelines = pipelines[:limit]
+	}
+	return pipelines, err
+}
+
+func (c *ContextRestClient) listAllPipelines(ctx context.Context, params *listPipelinesParams) ([]Pipeline, error) {
+	return paginate(ctx, params.PageToken, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]Pipeline, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listPipelines(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+}
+
+func (c *ContextRestClient) listPipelines(ctx context.Context, params *listPipelinesParams) (*listPipelinesResponse, error) {
+	req, err := c.newListPipelinesRequest(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var dest listPipelinesResponse
+	resp, _, err := c.send(ctx, req, nil, &dest)
+	if err != nil {
+		return nil, err
+	}
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newListPipelinesRequest(params *listPipelinesParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	path := "pipeline"
+	if params.ProjectSlug != nil {
+		path = fmt.Sprintf("project/%s/pipeline", *params.ProjectSlug)
+	}
+	queryURL, err = queryURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	urlParams := url.Values{}
+	if params.OrgSlug != nil {
+		urlParams.Add("org-slug", *params.OrgSlug)
+	}
+	if params.Mine {
+		urlParams.Add("mine", "true")
+	}
+	if params.Branch != nil {
+		urlParams.Add("branch", *params.Branch)
+	}
+	if params.Tag != nil {
+		urlParams.Add("tag", *params.Tag)
+	}
+	if params.PageToken != nil {
+		urlParams.Add("page-token", *params.PageToken)
+	}
+	queryURL.RawQuery = urlParams.Encode()
+
+	return c.newListRequest(queryURL.String())
+}
@@ -0,0 +1,372 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func newTestRestClient(server *httptest.Server, opts ...ContextRestOption) *ContextRestClient {
+	client, err := NewContextRestClient(settings.Config{
+		Host:       server.URL,
+		Endpoint:   "api/v2",
+		Token:      "token",
+		HTTPClient: http.DefaultClient,
+	}, opts...)
+	Expect(err).ToNot(HaveOccurred())
+	return client
+}
+
+var _ = ginkgo.Describe("Pipeline REST client", func() {
+	ginkgo.Describe("TriggerPipeline", func() {
+		var (
+			server   *httptest.Server
+			requests int
+			body     map[string]interface{}
+		)
+
+		ginkgo.BeforeEach(func() {
+			requests = 0
+			body = nil
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				requests++
+				Expect(req.Method).To(Equal("POST"))
+				Expect(req.URL.Path).To(Equal("/api/v2/project/gh/test-org/test-project/pipeline"))
+				Expect(json.NewDecoder(req.Body).Decode(&body)).To(Succeed())
+				rw.WriteHeader(http.StatusCreated)
+				_, err := rw.Write([]byte(`{"id": "pipeline-id", "number": 42, "state": "pending"}`))
+				Expect(err).ToNot(HaveOccurred())
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("accepts strings, numbers and booleans", func() {
+			client := newTestRestClient(server)
+			pipeline, err := client.TriggerPipeline("gh", "test-org", "test-project", "main", map[string]interface{}{
+				"name":    "value",
+				"count":   3,
+				"ratio":   0.5,
+				"enabled": true,
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pipeline.ID).To(Equal("pipeline-id"))
+			Expect(pipeline.Number).To(Equal(42))
+			Expect(requests).To(Equal(1))
+			Expect(body["branch"]).To(Equal("main"))
+			Expect(body["parameters"]).To(HaveKeyWithValue("enabled", true))
+		})
+
+		ginkgo.It("rejects other types before sending the request", func() {
+			client := newTestRestClient(server)
+			_, err := client.TriggerPipeline("gh", "test-org", "test-project", "main", map[string]interface{}{
+				"list": []string{"a", "b"},
+			})
+			Expect(err).To(MatchError("Invalid value for pipeline parameter 'list': expected a string, number or boolean, got []string"))
+			Expect(requests).To(Equal(0))
+		})
+
+		ginkgo.It("can have validation disabled", func() {
+			client := newTestRestClient(server, WithPipelineParameterValidator(nil))
+			_, err := client.TriggerPipeline("gh", "test-org", "test-project", "main", map[string]interface{}{
+				"map": map[string]string{"a": "b"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(requests).To(Equal(1))
+		})
+	})
+
+	ginkgo.Describe("GetPipelineConfig", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/api/v2/pipeline/pipeline-id/config":
+					_, _ = rw.Write([]byte(`{
+						"source": "version: 2.1\norbs:\n  node: circleci/node@5\n",
+						"compiled": "version: 2\njobs:\n  build: {}\n"
+					}`))
+				case "/api/v2/pipeline/pending-id/config":
+					_, _ = rw.Write([]byte(`{"source": "version: 2.1\n", "compiled": ""}`))
+				default:
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Pipeline not found"}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the source and compiled configuration", func() {
+			config, err := newTestRestClient(server).GetPipelineConfig("pipeline-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.Source).To(HavePrefix("version: 2.1\norbs:"))
+			Expect(config.Compiled).To(Equal("version: 2\njobs:\n  build: {}\n"))
+			Expect(config.SetupConfig).To(BeEmpty())
+		})
+
+		ginkgo.It("reports a pending pipeline", func() {
+			_, err := newTestRestClient(server).GetPipelineConfig("pending-id")
+			Expect(err).To(Equal(ErrPipelineConfigPending))
+		})
+
+		ginkgo.It("returns a NotFoundError for an unknown pipeline", func() {
+			_, err := newTestRestClient(server).GetPipelineConfig("missing")
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("ListPipelinesForProject", func() {
+		var (
+			server *httptest.Server
+			query  chan string
+		)
+
+		ginkgo.BeforeEach(func() {
+			query = make(chan string, 1)
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.URL.Path).To(Equal("/api/v2/project/gh/test-org/test-project/pipeline"))
+				query <- req.URL.RawQuery
+				_, _ = rw.Write([]byte(`{"items": [], "next_page_token": null}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("filters by branch", func() {
+			client := newTestRestClient(server)
+			_, err := client.ListPipelinesForProject("gh", "test-org", "test-project", PipelineFilter{Branch: "feature/x y"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-query).To(Equal("branch=feature%2Fx+y"))
+		})
+
+		ginkgo.It("filters by tag", func() {
+			client := newTestRestClient(server)
+			_, err := client.ListPipelinesForProject("gh", "test-org", "test-project", PipelineFilter{Tag: "v1.0.0"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-query).To(Equal("tag=v1.0.0"))
+		})
+
+		ginkgo.It("sends no filter by default", func() {
+			client := newTestRestClient(server)
+			_, err := client.ListPipelinesForProject("gh", "test-org", "test-project", PipelineFilter{})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-query).To(BeEmpty())
+		})
+
+		ginkgo.It("refuses to filter by both branch and tag", func() {
+			client := newTestRestClient(server)
+			_, err := client.ListPipelinesForProject("gh", "test-org", "test-project", PipelineFilter{Branch: "main", Tag: "v1.0.0"})
+			Expect(err).To(MatchError("Cannot filter pipelines by both a branch and a tag"))
+			Expect(query).To(BeEmpty())
+		})
+	})
+
+	ginkgo.Describe("LatestPipeline", func() {
+		var (
+			server   *httptest.Server
+			requests int
+		)
+
+		ginkgo.BeforeEach(func() {
+			// Pipelines are listed newest first.
+			pipelines := []Pipeline{
+				{ID: "feature", CreatedAt: time.Date(2021, 1, 3, 0, 0, 0, 0, time.UTC)},
+				{ID: "main-new", CreatedAt: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+				{ID: "main-old", CreatedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+			}
+			pipelines[0].VCS.Branch = "feature"
+			pipelines[1].VCS.Branch = "main"
+			pipelines[2].VCS.Branch = "main"
+
+			requests = 0
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				requests++
+				Expect(req.URL.Path).To(Equal("/api/v2/project/gh/test-org/test-project/pipeline"))
+				branch := req.URL.Query().Get("branch")
+				var items []Pipeline
+				for _, p := range pipelines {
+					if p.VCS.Branch == branch {
+						items = append(items, p)
+					}
+				}
+				// Serve one pipeline per page, to check that later pages aren't fetched.
+				resp := listPipelinesResponse{}
+				page := 0
+				if token := req.URL.Query().Get("page-token"); token != "" {
+					page, _ = strconv.Atoi(token)
+				}
+				if page < len(items) {
+					resp.Items = items[page : page+1]
+				}
+				if page+1 < len(items) {
+					next := strconv.Itoa(page + 1)
+					resp.NextPageToken = &next
+				}
+				Expect(json.NewEncoder(rw).Encode(resp)).To(Succeed())
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the most recently created pipeline on the branch", func() {
+			client := newTestRestClient(server)
+			pipeline, err := client.LatestPipeline("gh", "test-org", "test-project", "main")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(pipeline.ID).To(Equal("main-new"))
+			Expect(requests).To(Equal(1))
+		})
+
+		ginkgo.It("returns a NotFoundError when the branch has no pipelines", func() {
+			client := newTestRestClient(server)
+			_, err := client.LatestPipeline("gh", "test-org", "test-project", "missing")
+			Expect(err).To(MatchError("Cannot find a pipeline on branch 'missing'"))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("ListPipelinesForOrg", func() {
+		var (
+			server *httptest.Server
+			mine   []string
+		)
+
+		ginkgo.BeforeEach(func() {
+			mine = nil
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.URL.Path).To(Equal("/api/v2/pipeline"))
+				Expect(req.URL.Query().Get("org-slug")).To(Equal("gh/test-org"))
+				mine = append(mine, req.URL.Query().Get("mine"))
+
+				resp := listPipelinesResponse{}
+				switch req.URL.Query().Get("page-token") {
+				case "":
+					resp.Items = []Pipeline{{ID: "first"}, {ID: "second"}}
+					next := "page-2"
+					resp.NextPageToken = &next
+				case "page-2":
+					resp.Items = []Pipeline{{ID: "third"}}
+				}
+				Expect(json.NewEncoder(rw).Encode(resp)).To(Succeed())
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the pipelines from every page", func() {
+			client := newTestRestClient(server)
+			pipelines, err := client.ListPipelinesForOrg("gh", "test-org", false, 0)
+			Expect(err).ToNot(HaveOccurred())
+
+			var ids []string
+			for _, p := range *pipelines {
+				ids = append(ids, p.ID)
+			}
+			Expect(ids).To(Equal([]string{"first", "second", "third"}))
+			Expect(mine).To(Equal([]string{"", ""}))
+		})
+
+		ginkgo.It("fetches only as many pages as the limit needs", func() {
+			client := newTestRestClient(server)
+			pipelines, err := client.ListPipelinesForOrg("gh", "test-org", false, 1)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*pipelines).To(HaveLen(1))
+			Expect((*pipelines)[0].ID).To(Equal("first"))
+			Expect(mine).To(HaveLen(1))
+
+			mine = nil
+			pipelines, err = client.ListPipelinesForOrg("gh", "test-org", false, 3)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(*pipelines).To(HaveLen(3))
+			Expect(mine).To(HaveLen(2))
+		})
+
+		ginkgo.It("filters to the caller's pipelines", func() {
+			client := newTestRestClient(server)
+			_, err := client.ListPipelinesForOrg("gh", "test-org", true, 0)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(mine).To(Equal([]string{"true", "true"}))
+		})
+	})
+
+	ginkgo.Describe("MyPipelines", func() {
+		var (
+			server   *httptest.Server
+			requests []url.Values
+		)
+
+		ginkgo.BeforeEach(func() {
+			requests = nil
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.URL.Path).To(Equal("/api/v2/pipeline"))
+				requests = append(requests, req.URL.Query())
+
+				resp := listPipelinesResponse{}
+				switch req.URL.Query().Get("page-token") {
+				case "":
+					resp.Items = []Pipeline{{ID: "first"}, {ID: "second"}}
+					next := "page-2"
+					resp.NextPageToken = &next
+				case "page-2":
+					resp.Items = []Pipeline{{ID: "third"}}
+				}
+				Expect(json.NewEncoder(rw).Encode(resp)).To(Succeed())
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the caller's pipelines from every page", func() {
+			pipelines, err := newTestRestClient(server).MyPipelines()
+			Expect(err).ToNot(HaveOccurred())
+
+			var ids []string
+			for _, p := range *pipelines {
+				ids = append(ids, p.ID)
+			}
+			Expect(ids).To(Equal([]string{"first", "second", "third"}))
+			Expect(requests).To(HaveLen(2))
+			for _, query := range requests {
+				Expect(query.Get("mine")).To(Equal("true"))
+				Expect(query).ToNot(HaveKey("org-slug"))
+			}
+		})
+
+		ginkgo.It("returns errors", func() {
+			server.Close()
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(http.StatusUnauthorized)
+				_, _ = rw.Write([]byte(`{"message": "You must log in first."}`))
+			}))
+			_, err := newTestRestClient(server).MyPipelines()
+			Expect(err).To(MatchError("You must log in first."))
+		})
+	})
+})
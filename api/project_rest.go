@@ -0,0 +1,52 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// FollowProject follows a project, so that the caller is notified of its
+// builds, and returns the resulting follow status. The API v2 has no such
+// endpoint, so the API v1.1 endpoint next to it is used. It returns a
+// NotFoundError if there is no such project.
+func (c *ContextRestClient) FollowProject(vcs, org, project string, opts ...CallOption) (*FollowedProject, error) {
+	return c.setProjectFollowed(vcs, org, project, "follow", opts)
+}
+
+// UnfollowProject stops following a project, and returns the resulting
+// follow status, as for FollowProject.
+func (c *ContextRestClient) UnfollowProject(vcs, org, project string, opts ...CallOption) (*FollowedProject, error) {
+	return c.setProjectFollowed(vcs, org, project, "unfollow", opts)
+}
+
+func (c *ContextRestClient) setProjectFollowed(vcs, org, project, action string, opts []CallOption) (*FollowedProject, error) {
+	req, err := c.newFollowProjectRequest(vcs, org, project, action)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find project '%s'", c.toProjectSlug(vcs, org, project))}
+	}
+	var dest FollowedProject
+	if _, _, err := c.send(ctx, req, notFound, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newFollowProjectRequest(vcs, org, project, action string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("../v1.1/project/%s/%s", c.toProjectSlug(vcs, org, project), action))
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("POST", queryURL.String(), nil)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Following projects", func() {
+	var server *httptest.Server
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			defer ginkgo.GinkgoRecover()
+			Expect(req.Method).To(Equal("POST"))
+			Expect(req.Header.Get("circle-token")).To(Equal("token"))
+			switch req.URL.Path {
+			case "/api/v1.1/project/gh/test-org/test-project/follow":
+				_, _ = rw.Write([]byte(`{"followed": true}`))
+			case "/api/v1.1/project/gh/test-org/test-project/unfollow":
+				_, _ = rw.Write([]byte(`{"followed": false}`))
+			default:
+				rw.WriteHeader(http.StatusNotFound)
+				_, _ = rw.Write([]byte(`{"message": "Project not found"}`))
+			}
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("follows a project", func() {
+		status, err := newTestRestClient(server).FollowProject("gh", "test-org", "test-project")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Followed).To(BeTrue())
+	})
+
+	ginkgo.It("unfollows a project", func() {
+		status, err := newTestRestClient(server).UnfollowProject("gh", "test-org", "test-project")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(status.Followed).To(BeFalse())
+	})
+
+	ginkgo.It("returns a NotFoundError for an unknown project", func() {
+		_, err := newTestRestClient(server).FollowProject("gh", "test-org", "missing")
+		Expect(err).To(MatchError("Cannot find project 'gh/test-org/missing'"))
+		Expect(IsNotFoundError(err)).To(BeTrue())
+	})
+})
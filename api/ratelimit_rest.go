@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimitStatus reports the rate limit budget left, according to the
+// X-RateLimit-Remaining and X-RateLimit-Reset headers of the most recent
+// response which had them. remaining is how many more requests may be sent
+// before reset, the time at which the budget is replenished; reset is zero if
+// the API didn't say when that is. ok is false if no response has reported a
+// rate limit yet, in which case nothing is known about the budget.
+func (c *ContextRestClient) RateLimitStatus() (remaining int, reset time.Time, ok bool) {
+	return c.rateLimit.status()
+}
+
+// rateLimitTracker is safe for concurrent use.
+type rateLimitTracker struct {
+	mu        sync.Mutex
+	known     bool
+	remaining int
+	reset     time.Time
+}
+
+func (t *rateLimitTracker) status() (int, time.Time, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining, t.reset, t.known
+}
+
+// record updates the budget from the headers of resp. Responses without a
+// valid X-RateLimit-Remaining header are ignored. X-RateLimit-Reset is read as
+// a Unix time in seconds.
+func (t *rateLimitTracker) record(resp *http.Response) {
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	var reset time.Time
+	if seconds, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(seconds, 0)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.known = true
+	t.remaining = remaining
+	t.reset = reset
+}
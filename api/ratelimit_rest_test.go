@@ -0,0 +1,88 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("RateLimitStatus", func() {
+	var (
+		server    *httptest.Server
+		client    *ContextRestClient
+		mu        sync.Mutex
+		remaining int
+		reset     time.Time
+	)
+
+	ginkgo.BeforeEach(func() {
+		remaining = 100
+		reset = time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			if remaining >= 0 {
+				rw.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+				rw.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+				remaining--
+			}
+			mu.Unlock()
+			_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("isn't known before any response", func() {
+		_, _, ok := client.RateLimitStatus()
+		Expect(ok).To(BeFalse())
+	})
+
+	ginkgo.It("reflects the most recent response", func() {
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+
+		left, resetAt, ok := client.RateLimitStatus()
+		Expect(ok).To(BeTrue())
+		Expect(left).To(Equal(99))
+		Expect(resetAt.Equal(reset)).To(BeTrue())
+	})
+
+	ginkgo.It("keeps the last known budget when a response has no headers", func() {
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		mu.Lock()
+		remaining = -1
+		mu.Unlock()
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+
+		left, _, ok := client.RateLimitStatus()
+		Expect(ok).To(BeTrue())
+		Expect(left).To(Equal(100))
+	})
+
+	ginkgo.It("is safe to read while requests are in flight", func() {
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer ginkgo.GinkgoRecover()
+				Expect(client.DeleteContext("context-id")).To(Succeed())
+				_, _, ok := client.RateLimitStatus()
+				Expect(ok).To(BeTrue())
+			}()
+		}
+		wg.Wait()
+
+		left, _, _ := client.RateLimitStatus()
+		Expect(left).To(BeNumerically(">=", 91))
+	})
+})
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// DoRaw sends a request to an endpoint the client doesn't wrap yet, and
+// returns the response as is, whatever its status, for the caller to handle.
+// path is resolved against the API's base URL, so "context/ID/restrictions"
+// names an endpoint of the API v2, and "/api/v1.1/me" one of another version;
+// URLs on other hosts are refused, so that the token isn't sent there. The
+// request is authorized and sent like those of the other methods, including
+// retries if the client is configured WithRetries; only a body which is a
+// *bytes.Reader, *bytes.Buffer or *strings.Reader can be sent again.
+//
+// The caller must close the body of the response.
+func (c *ContextRestClient) DoRaw(method, path string, body io.Reader) (*http.Response, error) {
+	server, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err := server.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	if !c.onServer(queryURL) {
+		return nil, fmt.Errorf("Refusing to send a request to %s, which is not on the API's host", queryURL.Redacted())
+	}
+
+	req, err := c.newHTTPRequest(method, queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req.WithContext(context.Background()))
+}
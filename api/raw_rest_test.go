@@ -0,0 +1,68 @@
+package api
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("DoRaw", func() {
+	var (
+		server  *httptest.Server
+		client  *ContextRestClient
+		request *http.Request
+		body    string
+	)
+
+	ginkgo.BeforeEach(func() {
+		request = nil
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			request = req
+			raw, _ := ioutil.ReadAll(req.Body)
+			body = string(raw)
+			rw.Header().Set("X-Custom", "custom")
+			rw.WriteHeader(http.StatusTeapot)
+			_, _ = rw.Write([]byte(`{"message": "I'm a teapot"}`))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("returns the response unconsumed, with auth applied", func() {
+		resp, err := client.DoRaw("POST", "context/context-id/restrictions", strings.NewReader(`{"project_id": "id"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(resp.StatusCode).To(Equal(http.StatusTeapot))
+		Expect(resp.Header.Get("X-Custom")).To(Equal("custom"))
+		raw, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(raw)).To(Equal(`{"message": "I'm a teapot"}`))
+
+		Expect(request.Method).To(Equal("POST"))
+		Expect(request.URL.Path).To(Equal("/api/v2/context/context-id/restrictions"))
+		Expect(request.Header.Get("Circle-Token")).To(Equal("token"))
+		Expect(body).To(Equal(`{"project_id": "id"}`))
+	})
+
+	ginkgo.It("resolves absolute paths against the API's host", func() {
+		resp, err := client.DoRaw("GET", "/api/v1.1/me", nil)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+		Expect(request.URL.Path).To(Equal("/api/v1.1/me"))
+	})
+
+	ginkgo.It("refuses URLs on other hosts", func() {
+		_, err := client.DoRaw("GET", "https://elsewhere.example/me", nil)
+		Expect(err).To(MatchError("Refusing to send a request to https://elsewhere.example/me, which is not on the API's host"))
+		Expect(request).To(BeNil())
+	})
+})
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"errors"
+	"strings"
+)
+
+// redactedValue replaces secret values in anything the client reports.
+const redactedValue = "****"
+
+// RedactValue masks a secret value, such as the value of an environment
+// variable, for display. The mask doesn't reveal the value's length.
+func RedactValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return redactedValue
+}
+
+// redactError returns err with every occurrence of value masked, so that a
+// server echoing a secret back in an error response doesn't leak it into the
+// logs of whoever reports the error. APIErrors and DecodeErrors keep their
+// type; other errors are wrapped.
+func redactError(err error, value string) error {
+	if err == nil || value == "" || !strings.Contains(err.Error(), value) {
+		return err
+	}
+	redact := func(s string) string {
+		return strings.ReplaceAll(s, value, RedactValue(value))
+	}
+
+	switch e := err.(type) {
+	case *APIError:
+		return &APIError{StatusCode: e.StatusCode, Message: redact(e.Message), Body: redact(e.Body), RequestID: e.RequestID}
+	case *DecodeError:
+		return &DecodeError{StatusCode: e.StatusCode, Snippet: redact(e.Snippet), Err: redactError(e.Err, value)}
+	}
+	return &redactedError{message: redact(err.Error()), err: redactError(errors.Unwrap(err), value)}
+}
+
+// redactedError is an error whose message has had a secret masked. It
+// unwraps to the error it wrapped, masked in turn, so that errors.Is and
+// errors.As work without reaching the secret.
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (e *redactedError) Error() string {
+	return e.message
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
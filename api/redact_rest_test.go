@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Redaction", func() {
+	const secret = "s3cr3t-value"
+
+	var (
+		server      *httptest.Server
+		status      int
+		contentType string
+		body        string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("Content-Type", contentType)
+			rw.WriteHeader(status)
+			_, _ = rw.Write([]byte(body))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("masks values", func() {
+		Expect(RedactValue(secret)).To(Equal("****"))
+		Expect(RedactValue("")).To(BeEmpty())
+	})
+
+	ginkgo.It("masks a value echoed in a JSON error", func() {
+		status, contentType = http.StatusBadRequest, "application/json"
+		body = fmt.Sprintf(`{"message": "Invalid value '%s'"}`, secret)
+
+		err := newTestRestClient(server).CreateEnvironmentVariable("context-id", "FOO", secret)
+		Expect(err).To(MatchError("Invalid value '****'"))
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusBadRequest))
+	})
+
+	ginkgo.It("masks a value echoed in a text error", func() {
+		status, contentType = http.StatusBadGateway, "text/plain"
+		body = "upstream rejected " + secret
+
+		_, err := newTestRestClient(server).PutEnvironmentVariable("context-id", "FOO", secret)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).ToNot(ContainSubstring(secret))
+		Expect(err.(*APIError).Body).To(Equal("upstream rejected ****"))
+	})
+
+	ginkgo.It("masks a value echoed in an undecodable response", func() {
+		status, contentType = http.StatusOK, "text/html"
+		body = "<html>" + secret + "</html>"
+
+		_, err := newTestRestClient(server).PutEnvironmentVariable("context-id", "FOO", secret)
+		var decodeErr *DecodeError
+		Expect(errors.As(err, &decodeErr)).To(BeTrue())
+		Expect(err.Error()).ToNot(ContainSubstring(secret))
+		Expect(decodeErr.Snippet).To(Equal("<html>****</html>"))
+	})
+
+	ginkgo.It("masks values in other errors but keeps them unwrappable", func() {
+		err := redactError(fmt.Errorf("wrapped %s: %w", secret, ErrEmptyResponseBody), secret)
+		Expect(err.Error()).To(Equal("wrapped ****: The server returned an empty response body"))
+		Expect(errors.Is(err, ErrEmptyResponseBody)).To(BeTrue())
+		Expect(redactError(ErrEmptyResponseBody, secret)).To(Equal(ErrEmptyResponseBody))
+	})
+
+	ginkgo.It("masks values in the errors it unwraps to", func() {
+		apiErr := &APIError{StatusCode: http.StatusBadRequest, Message: "rejected " + secret}
+		err := redactError(fmt.Errorf("wrapped %s: %w", secret, fmt.Errorf("inner %s: %w", secret, apiErr)), secret)
+		for inner := errors.Unwrap(err); inner != nil; inner = errors.Unwrap(inner) {
+			Expect(inner.Error()).ToNot(ContainSubstring(secret))
+		}
+		var unwrapped *APIError
+		Expect(errors.As(err, &unwrapped)).To(BeTrue())
+		Expect(unwrapped.Message).To(Equal("rejected ****"))
+	})
+})
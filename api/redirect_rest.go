@@ -0,0 +1,74 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// maxRedirects matches the limit of http.Client's default redirect policy.
+const maxRedirects = 10
+
+// authHeaders are the headers which may carry the API token.
+var authHeaders = []string{"circle-token", "Authorization"}
+
+// withRedirectPolicy returns a copy of client which follows redirects using
+// checkRedirect. The caller's client is left unmodified, since it may be
+// shared with code which expects its own redirect policy.
+func withRedirectPolicy(client *http.Client) *http.Client {
+	redirecting := &http.Client{}
+	if client != nil {
+		*redirecting = *client
+	}
+	redirecting.CheckRedirect = checkRedirect
+	return redirecting
+}
+
+// checkRedirect follows redirects within the host of the original request,
+// re-attaching its auth headers, and refuses redirects to any other host or
+// from https to http, so that the token is never sent anywhere but the server
+// the client was configured with. Requests made with a context from
+// withForeignRedirects may also be redirected to other hosts, but without
+// their auth headers.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.Errorf("Stopped after %d redirects", maxRedirects)
+	}
+	original := via[0]
+	if req.URL.Host != original.URL.Host {
+		if !followsForeignRedirects(req.Context()) {
+			return errors.Errorf("Refusing to follow a redirect from %s to a different host, %s", original.URL.Host, req.URL.Host)
+		}
+		if original.URL.Scheme == "https" && req.URL.Scheme != "https" {
+			return errors.Errorf("Refusing to follow a redirect from https to %s", req.URL.Scheme)
+		}
+		for _, header := range authHeaders {
+			req.Header.Del(header)
+		}
+		return nil
+	}
+	if original.URL.Scheme == "https" && req.URL.Scheme != "https" {
+		return errors.Errorf("Refusing to follow a redirect from https to %s", req.URL.Scheme)
+	}
+	for _, header := range authHeaders {
+		if value := original.Header.Get(header); value != "" {
+			req.Header.Set(header, value)
+		}
+	}
+	return nil
+}
+
+type foreignRedirectsKey struct{}
+
+// withForeignRedirects returns a copy of ctx which lets requests made with it
+// follow redirects to other hosts, such as those from an artifact's URL to the
+// storage holding it.
+func withForeignRedirects(ctx context.Context) context.Context {
+	return context.WithValue(ctx, foreignRedirectsKey{}, true)
+}
+
+func followsForeignRedirects(ctx context.Context) bool {
+	follow, _ := ctx.Value(foreignRedirectsKey{}).(bool)
+	return follow
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Redirects", func() {
+	var (
+		server *httptest.Server
+		other  *httptest.Server
+		tokens []string
+	)
+
+	ginkgo.BeforeEach(func() {
+		tokens = nil
+		other = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			tokens = append(tokens, req.Header.Get("circle-token"))
+			_, _ = rw.Write([]byte(`{"items": []}`))
+		}))
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/api/v2/context":
+				http.Redirect(rw, req, "/api/v2/moved/context?"+req.URL.RawQuery, http.StatusMovedPermanently)
+			case "/api/v2/context/context-id":
+				http.Redirect(rw, req, other.URL+req.URL.Path, http.StatusFound)
+			default:
+				tokens = append(tokens, req.Header.Get("circle-token"))
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			}
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+		other.Close()
+	})
+
+	ginkgo.It("follows same-host redirects with the token", func() {
+		client := newTestRestClient(server)
+		contexts, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*contexts).To(BeEmpty())
+		Expect(tokens).To(Equal([]string{"token"}))
+	})
+
+	ginkgo.It("refuses cross-host redirects", func() {
+		client := newTestRestClient(server)
+		err := client.DeleteContext("context-id")
+		Expect(err).To(MatchError(ContainSubstring("Refusing to follow a redirect")))
+		Expect(tokens).To(BeEmpty())
+	})
+
+	ginkgo.It("leaves the configured http.Client unmodified", func() {
+		httpClient := &http.Client{}
+		client, err := NewContextRestClient(settings.Config{
+			Host:       server.URL,
+			Endpoint:   "api/v2",
+			Token:      "token",
+			HTTPClient: httpClient,
+		})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.client).ToNot(BeIdenticalTo(httpClient))
+		Expect(httpClient.CheckRedirect).To(BeNil())
+		Expect(http.DefaultClient.CheckRedirect).To(BeNil())
+	})
+})
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+)
+
+// requestIDHeader is the header in which CircleCI identifies each request,
+// for support to look it up.
+const requestIDHeader = "X-Request-Id"
+
+// LastRequestID returns the X-Request-Id header of the most recent response,
+// to quote when asking CircleCI support about it. It is empty if no response
+// has been received yet, or if the most recent one had no such header.
+// Failed requests also carry their ID in APIError.RequestID.
+func (c *ContextRestClient) LastRequestID() string {
+	return c.lastRequestID.get()
+}
+
+// requestIDTracker is safe for concurrent use.
+type requestIDTracker struct {
+	mu sync.Mutex
+	id string
+}
+
+func (t *requestIDTracker) get() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.id
+}
+
+func (t *requestIDTracker) record(resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.id = resp.Header.Get(requestIDHeader)
+}
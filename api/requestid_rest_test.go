@@ -0,0 +1,78 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Request IDs", func() {
+	var (
+		server    *httptest.Server
+		client    *ContextRestClient
+		requestID string
+		status    int
+		body      string
+	)
+
+	ginkgo.BeforeEach(func() {
+		requestID = "request-1"
+		status = http.StatusOK
+		body = `{"message": "Context deleted."}`
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if requestID != "" {
+				rw.Header().Set("X-Request-Id", requestID)
+			}
+			rw.WriteHeader(status)
+			_, _ = rw.Write([]byte(body))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("propagates into API errors", func() {
+		status = http.StatusInternalServerError
+		body = `{"message": "Something went wrong."}`
+		err := client.DeleteContext("context-id")
+
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.RequestID).To(Equal("request-1"))
+		Expect(err).To(MatchError("Something went wrong."))
+	})
+
+	ginkgo.It("survives the redaction of secrets from errors", func() {
+		status = http.StatusBadRequest
+		body = `{"message": "Invalid value s3cr3t"}`
+		err := client.CreateEnvironmentVariable("context-id", "FOO", "s3cr3t")
+
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.Message).To(Equal("Invalid value ****"))
+		Expect(apiErr.RequestID).To(Equal("request-1"))
+	})
+
+	ginkgo.It("reports the ID of the most recent response", func() {
+		Expect(client.LastRequestID()).To(BeEmpty())
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.LastRequestID()).To(Equal("request-1"))
+
+		requestID = "request-2"
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.LastRequestID()).To(Equal("request-2"))
+	})
+
+	ginkgo.It("doesn't keep a stale ID when a response has none", func() {
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		requestID = ""
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(client.LastRequestID()).To(BeEmpty())
+	})
+})
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// A resource is the body of a successful response which must have certain
+// fields set. Some misconfigured gateways answer 200 OK with an error body,
+// which would otherwise decode into a zero value without complaint.
+type resource interface {
+	// missingField returns the JSON name of a required field which isn't
+	// set, or "" if there is none.
+	missingField() string
+}
+
+func (c Context) missingField() string {
+	if c.ID == "" {
+		return "id"
+	}
+	return ""
+}
+
+func (p Pipeline) missingField() string {
+	if p.ID == "" {
+		return "id"
+	}
+	return ""
+}
+
+func (w Workflow) missingField() string {
+	if w.ID == "" {
+		return "id"
+	}
+	return ""
+}
+
+// checkResource returns an error if dest is a resource missing a required
+// field. If the body carries an error message, as in {"message": "..."} or
+// {"error": "..."}, that is surfaced as an APIError; otherwise the result is
+// a DecodeError.
+func checkResource(resp *http.Response, bodyBytes []byte, dest interface{}) error {
+	checked, ok := dest.(resource)
+	if !ok {
+		return nil
+	}
+	field := checked.missingField()
+	if field == "" {
+		return nil
+	}
+
+	var body struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	_ = json.Unmarshal(bodyBytes, &body)
+	message := body.Message
+	if message == "" {
+		message = body.Error
+	}
+	if message != "" {
+		return &APIError{StatusCode: resp.StatusCode, Message: message, RequestID: resp.Header.Get(requestIDHeader)}
+	}
+	return newDecodeError(resp.StatusCode, bodyBytes, fmt.Errorf("the required field '%s' is missing", field))
+}
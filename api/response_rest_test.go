@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Successful responses with an error body", func() {
+	var (
+		server *httptest.Server
+		client *ContextRestClient
+		body   string
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("X-Request-Id", "request-id")
+			_, _ = rw.Write([]byte(body))
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("surfaces the message of a created context without an ID", func() {
+		body = `{"message": "Upstream unavailable"}`
+		err := client.CreateContext("gh", "test-org", "ctx")
+
+		var apiErr *APIError
+		Expect(errors.As(err, &apiErr)).To(BeTrue())
+		Expect(apiErr.StatusCode).To(Equal(http.StatusOK))
+		Expect(apiErr.RequestID).To(Equal("request-id"))
+		Expect(err).To(MatchError("Upstream unavailable"))
+	})
+
+	ginkgo.It("surfaces an error field", func() {
+		body = `{"error": "Bad gateway configuration"}`
+		_, err := client.GetWorkflow("workflow-id")
+		Expect(err).To(MatchError("Bad gateway configuration"))
+	})
+
+	ginkgo.It("reports a missing required field without a message", func() {
+		body = `{"number": 1}`
+		_, err := client.TriggerPipeline("gh", "test-org", "project", "main", nil)
+		Expect(err).To(BeAssignableToTypeOf(&DecodeError{}))
+		Expect(err).To(MatchError(ContainSubstring("the required field 'id' is missing")))
+	})
+
+	ginkgo.It("accepts messages from methods which expect them", func() {
+		body = `{"message": "Context deleted."}`
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+	})
+})
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// A ContextRestrictionStatus is a context, and whether access to it is
+// restricted, for example to certain projects.
+type ContextRestrictionStatus struct {
+	Context    Context
+	Restricted bool
+}
+
+// ContextsWithRestrictionStatus returns every context owned by the given org,
+// in the order they are listed, along with whether each has any restrictions,
+// so that contexts any project of the org can use can be flagged. The
+// restrictions of the contexts are looked up concurrently, as configured by
+// opts. If some lookups fail, the other contexts are still returned alongside
+// a *BatchError keyed by context ID. Cancelling ctx stops the lookups, and
+// its error is returned.
+func (c *ContextRestClient) ContextsWithRestrictionStatus(ctx context.Context, vcs, org string, opts ...BatchOption) ([]ContextRestrictionStatus, error) {
+	contexts, err := c.listAllContexts(ctx, c.ownerParams(vcs, org))
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(contexts))
+	for _, context := range contexts {
+		ids = append(ids, context.ID)
+	}
+
+	var mu sync.Mutex
+	restricted := make(map[string]bool, len(ids))
+	err = runBatch(ctx, ids, opts, func(ctx context.Context, contextID string) error {
+		hasRestrictions, err := c.hasRestrictions(ctx, contextID)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		restricted[contextID] = hasRestrictions
+		mu.Unlock()
+		return nil
+	})
+
+	statuses := make([]ContextRestrictionStatus, 0, len(contexts))
+	for _, context := range contexts {
+		if hasRestrictions, ok := restricted[context.ID]; ok {
+			statuses = append(statuses, ContextRestrictionStatus{Context: context, Restricted: hasRestrictions})
+		}
+	}
+	return statuses, err
+}
+
+type listRestrictionsResponse struct {
+	Items []json.RawMessage `json:"items"`
+}
+
+// hasRestrictions reports whether the context has any restrictions. Only the
+// first page of restrictions is needed to tell.
+func (c *ContextRestClient) hasRestrictions(ctx context.Context, contextID string) (bool, error) {
+	req, err := c.newListRestrictionsRequest(contextID)
+	if err != nil {
+		return false, err
+	}
+
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find context with ID '%s'", contextID)}
+	}
+	var dest listRestrictionsResponse
+	if _, _, err := c.send(ctx, req, notFound, &dest); err != nil {
+		return false, err
+	}
+	return len(dest.Items) > 0, nil
+}
+
+func (c *ContextRestClient) newListRestrictionsRequest(contextID string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("context/%s/restrictions", contextID))
+	if err != nil {
+		return nil, err
+	}
+	return c.newListRequest(queryURL.String())
+}
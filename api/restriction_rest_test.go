@@ -0,0 +1,61 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ContextsWithRestrictionStatus", func() {
+	var (
+		server *httptest.Server
+		client *ContextRestClient
+	)
+
+	ginkgo.BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case "/api/v2/context":
+				if req.URL.Query().Get("owner-slug") != "gh/test-org" {
+					rw.WriteHeader(http.StatusForbidden)
+					_, _ = rw.Write([]byte(`{"message": "Permission denied."}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"items": [{"id": "restricted", "name": "deploy"}, {"id": "open", "name": "shared"}, {"id": "broken", "name": "legacy"}]}`))
+			case "/api/v2/context/restricted/restrictions":
+				_, _ = rw.Write([]byte(`{"items": [{"id": "r-1", "restriction_type": "project", "restriction_value": "project-id"}]}`))
+			case "/api/v2/context/open/restrictions":
+				_, _ = rw.Write([]byte(`{"items": []}`))
+			default:
+				rw.WriteHeader(http.StatusInternalServerError)
+				_, _ = rw.Write([]byte(`{"message": "Something broke."}`))
+			}
+		}))
+		client = newTestRestClient(server)
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("flags the contexts without restrictions, keeping the others on errors", func() {
+		statuses, err := client.ContextsWithRestrictionStatus(context.Background(), "gh", "test-org")
+		Expect(statuses).To(Equal([]ContextRestrictionStatus{
+			{Context: Context{ID: "restricted", Name: "deploy"}, Restricted: true},
+			{Context: Context{ID: "open", Name: "shared"}, Restricted: false},
+		}))
+		Expect(err).To(BeAssignableToTypeOf(&BatchError{}))
+		Expect(err.(*BatchError).Errors).To(HaveLen(1))
+		Expect(err.(*BatchError).Errors).To(HaveKeyWithValue("broken", MatchError("Something broke.")))
+	})
+
+	ginkgo.It("fails if the contexts can't be listed", func() {
+		statuses, err := client.ContextsWithRestrictionStatus(context.Background(), "gh", "forbidden-org")
+		Expect(err).To(MatchError("Permission denied."))
+		Expect(statuses).To(BeNil())
+	})
+})
@@ -0,0 +1,347 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 500 * time.Millisecond
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// WithRetries makes the client retry requests which fail with a retryable
+// error (see IsRetryable), making at most maxAttempts attempts in total.
+// Between attempts the client waits for an exponentially increasing delay,
+// randomly jittered so that concurrent requests failing together don't all
+// retry at the same instant, unless a 429 or 503 response says how long to
+// wait with a Retry-After header.
+//
+// Only idempotent requests, such as GET, PUT and DELETE, are retried after a
+// server error or a network failure, since the server may already have acted
+// on the failed attempt. Other requests, such as the POSTs which trigger a
+// pipeline or create a context, are only retried when they are rate limited.
+func WithRetries(maxAttempts int) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithRetryMaxDelay caps the delay between retry attempts, which otherwise
+// doubles with each attempt, so that a long run of failures doesn't make the
+// client appear to hang. The cap also applies to the delays the server asks
+// for with Retry-After. The default cap is 30 seconds.
+func WithRetryMaxDelay(maxDelay time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.retryMaxDelay = maxDelay
+	}
+}
+
+// A RetryPredicate reports whether an attempt which returned resp and err
+// should be retried. resp is nil if the attempt failed without a response.
+type RetryPredicate func(resp *http.Response, err error) bool
+
+// WithRetryPredicate makes the client also retry attempts for which predicate
+// returns true, such as responses carrying a particular error message, on top
+// of those it retries by default. As with the default retries, the number of
+// attempts is limited by WithRetries or WithCallRetries. The predicate is
+// consulted for every method, including POST, so it should only return true
+// for failures which are known to be safe to retry. It may read the body of a
+// response, which is buffered so that it can still be read afterwards.
+func WithRetryPredicate(predicate RetryPredicate) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.retryPredicate = predicate
+	}
+}
+
+// WithRetryBudget limits the retries the client makes across all of its
+// requests to a token bucket holding at most retries tokens, which refills at
+// a rate of retries tokens per period. Each retry takes a token; once the
+// bucket is empty, failing requests return their original error instead of
+// being retried, so that a client busy with many requests doesn't pile retries
+// onto an API which is already struggling. The budget applies on top of
+// WithRetries, which still limits the attempts at each request.
+func WithRetryBudget(retries int, period time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.retryBudget = newRetryBudget(retries, period)
+	}
+}
+
+// retryBudget is a token bucket of retries, safe for concurrent use.
+type retryBudget struct {
+	mu       sync.Mutex
+	tokens   float64
+	capacity float64
+	perToken time.Duration
+	last     time.Time
+	now      func() time.Time
+}
+
+func newRetryBudget(retries int, period time.Duration) *retryBudget {
+	b := &retryBudget{
+		tokens:   float64(retries),
+		capacity: float64(retries),
+		now:      time.Now,
+	}
+	if retries > 0 {
+		b.perToken = period / time.Duration(retries)
+	}
+	b.last = b.now()
+	return b
+}
+
+// take takes a token from the bucket, reporting whether there was one.
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if b.perToken > 0 {
+		b.tokens += float64(now.Sub(b.last)) / float64(b.perToken)
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// WithJitterSource sets the source of randomness used to jitter retry delays.
+// It is mostly useful for making retries deterministic in tests.
+func WithJitterSource(source rand.Source) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.jitter = &lockedRand{rand: rand.New(source)}
+	}
+}
+
+// lockedRand is a *rand.Rand which is safe for concurrent use.
+type lockedRand struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+func (r *lockedRand) Int63n(n int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rand.Int63n(n)
+}
+
+// do sends req, retrying it as configured by WithRetries or WithCallRetries.
+// The caller must close the body of the returned response.
+func (c *ContextRestClient) do(req *http.Request) (*http.Response, error) {
+	if c.clientCtx.Err() != nil {
+		return nil, ErrClientClosed
+	}
+	req, stop := c.withClientContext(req)
+	resp, err := c.doAttempts(req)
+	if err != nil && c.clientCtx.Err() != nil {
+		err = ErrClientClosed
+	}
+	if resp == nil || err != nil {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		stop()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: stop}
+	return resp, nil
+}
+
+func (c *ContextRestClient) doAttempts(req *http.Request) (*http.Response, error) {
+	maxAttempts := c.attemptsFor(req)
+	for attempt := 1; ; attempt++ {
+		if c.breaker != nil && !c.breaker.allow() {
+			return nil, ErrCircuitOpen
+		}
+		attemptReq, endSpan := c.traceAttempt(req)
+		if err := c.prepareAttempt(attemptReq); err != nil {
+			if c.breaker != nil {
+				c.breaker.record(nil, err, true)
+			}
+			endSpan(0, err)
+			return nil, err
+		}
+		resp, err := c.client.Do(attemptReq)
+		if c.breaker != nil {
+			c.breaker.record(resp, err, req.Context().Err() != nil)
+		}
+		if resp != nil {
+			c.rateLimit.record(resp)
+			c.lastRequestID.record(resp)
+			endSpan(resp.StatusCode, err)
+		} else {
+			endSpan(0, err)
+		}
+		if attempt >= maxAttempts {
+			return resp, err
+		}
+		retry, checkErr := c.shouldRetry(req.Method, resp, err)
+		if checkErr != nil {
+			return nil, checkErr
+		}
+		if !retry {
+			return resp, err
+		}
+		if req.Body != nil && req.GetBody == nil {
+			// The body has been consumed and can't be sent again.
+			return resp, err
+		}
+		if c.retryBudget != nil && !c.retryBudget.take() {
+			return resp, err
+		}
+		delay := c.retryDelay(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+// prepareAttempt authorizes attemptReq and runs the request editors on it.
+func (c *ContextRestClient) prepareAttempt(attemptReq *http.Request) error {
+	if err := c.authorize(attemptReq); err != nil {
+		return err
+	}
+	return c.editRequest(attemptReq)
+}
+
+// shouldRetry reports whether the attempt which returned resp and err should
+// be retried, consulting the predicate set by WithRetryPredicate if the
+// default logic wouldn't retry it. It only returns an error if resp's body
+// can't be buffered for the predicate, in which case the body has been closed.
+func (c *ContextRestClient) shouldRetry(method string, resp *http.Response, err error) (bool, error) {
+	if shouldRetry(method, resp, err) {
+		return true, nil
+	}
+	if c.retryPredicate == nil {
+		return false, nil
+	}
+	if resp == nil || err != nil {
+		return c.retryPredicate(resp, err), nil
+	}
+
+	body, readErr := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if readErr != nil {
+		return false, readErr
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	retry := c.retryPredicate(resp, nil)
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return retry, nil
+}
+
+func shouldRetry(method string, resp *http.Response, err error) bool {
+	if !isIdempotent(method) {
+		// A rate limited request was refused without being acted on.
+		return err == nil && resp.StatusCode == http.StatusTooManyRequests
+	}
+	if err != nil {
+		return IsRetryable(err)
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// isIdempotent reports whether sending a request with the given method more
+// than once has the same effect as sending it once.
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff returns how long to wait before the attempt following the given
+// one. It uses "equal jitter": half of the exponential delay, capped by
+// WithRetryMaxDelay, is fixed and the other half is random.
+func (c *ContextRestClient) backoff(attempt int) time.Duration {
+	delay := c.retryBaseDelay << uint(attempt-1)
+	// A large enough shift overflows, so check the sign as well as the cap.
+	if delay > c.retryMaxDelay || delay <= 0 {
+		delay = c.retryMaxDelay
+	}
+	half := int64(delay / 2)
+	if half <= 0 {
+		return delay
+	}
+	return time.Duration(half + c.jitter.Int63n(half))
+}
+
+// retryDelay returns how long to wait after the given attempt, which got
+// resp. When a 429 or 503 response says how long to wait with a Retry-After
+// header, as the API does when rate limiting or during maintenance, that is
+// preferred over the computed backoff, but still capped by the max delay.
+func (c *ContextRestClient) retryDelay(attempt int, resp *http.Response) time.Duration {
+	delay, ok := retryAfter(resp, time.Now())
+	if !ok {
+		return c.backoff(attempt)
+	}
+	if delay > c.retryMaxDelay {
+		delay = c.retryMaxDelay
+	}
+	return delay
+}
+
+// retryAfter parses the Retry-After header of a 429 or 503 response, which
+// is either a number of seconds or an HTTP date.
+func retryAfter(resp *http.Response, now time.Time) (time.Duration, bool) {
+	if resp == nil || (resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := date.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,325 @@
+package api
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Retries", func() {
+	ginkgo.It("retries rate limited requests until they succeed", func() {
+		fake, server, client := newFakeContextServer(WithRetries(3))
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+		id := fake.addContext("ctx", time.Now(), "FOO")
+
+		failures := 2
+		fake.fail = func(*http.Request) int {
+			if failures > 0 {
+				failures--
+				return http.StatusTooManyRequests
+			}
+			return 0
+		}
+
+		Expect(client.CreateEnvironmentVariable(id, "BAR", "value")).To(Succeed())
+		Expect(fake.Requests()).To(HaveLen(3))
+	})
+
+	ginkgo.It("gives up after the maximum number of attempts", func() {
+		fake, server, client := newFakeContextServer(WithRetries(2))
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+		fake.fail = func(*http.Request) int { return http.StatusServiceUnavailable }
+
+		Expect(client.DeleteContext("context-id")).To(MatchError("Service Unavailable"))
+		Expect(fake.Requests()).To(HaveLen(2))
+	})
+
+	ginkgo.It("does not retry by default, nor on client errors", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		fake.fail = func(*http.Request) int { return http.StatusServiceUnavailable }
+		Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+		Expect(fake.Requests()).To(HaveLen(1))
+
+		fake, server, client = newFakeContextServer(WithRetries(3))
+		defer server.Close()
+		fake.fail = func(*http.Request) int { return http.StatusBadRequest }
+		Expect(client.DeleteContext("context-id")).ToNot(Succeed())
+		Expect(fake.Requests()).To(HaveLen(1))
+	})
+
+	ginkgo.It("retries POSTs only when they are rate limited", func() {
+		fake, server, client := newFakeContextServer(WithRetries(3))
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+		fake.fail = func(*http.Request) int { return http.StatusServiceUnavailable }
+		Expect(client.CreateContext("gh", "test-org", "ctx")).ToNot(Succeed())
+		Expect(fake.Requests()).To(Equal([]string{"POST /api/v2/context"}))
+
+		fake, server, client = newFakeContextServer(WithRetries(3))
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+		failures := 1
+		fake.fail = func(*http.Request) int {
+			if failures > 0 {
+				failures--
+				return http.StatusTooManyRequests
+			}
+			return 0
+		}
+		Expect(client.CreateContext("gh", "test-org", "ctx")).To(Succeed())
+		Expect(fake.Requests()).To(Equal([]string{"POST /api/v2/context", "POST /api/v2/context"}))
+	})
+
+	ginkgo.It("stops retrying once the retry budget is spent", func() {
+		fake, server, client := newFakeContextServer(WithRetries(3), WithRetryBudget(4, time.Hour))
+		defer server.Close()
+		client.retryBaseDelay = time.Millisecond
+		fake.fail = func(*http.Request) int { return http.StatusServiceUnavailable }
+
+		// Two requests spend the budget on two retries each...
+		for i := 0; i < 4; i++ {
+			Expect(client.DeleteContext("context-id")).To(MatchError("Service Unavailable"))
+		}
+		// ...and the other two make a single attempt each.
+		Expect(fake.Requests()).To(HaveLen(3 + 3 + 1 + 1))
+	})
+
+	ginkgo.It("refills the retry budget over time", func() {
+		budget := newRetryBudget(2, time.Minute)
+		now := budget.last
+		budget.now = func() time.Time { return now }
+
+		Expect(budget.take()).To(BeTrue())
+		Expect(budget.take()).To(BeTrue())
+		Expect(budget.take()).To(BeFalse())
+
+		now = now.Add(30 * time.Second)
+		Expect(budget.take()).To(BeTrue())
+		Expect(budget.take()).To(BeFalse())
+
+		now = now.Add(time.Hour)
+		Expect(budget.take()).To(BeTrue())
+		Expect(budget.take()).To(BeTrue())
+		Expect(budget.take()).To(BeFalse())
+	})
+
+	ginkgo.It("jitters the delay between attempts", func() {
+		_, server, client := newFakeContextServer(WithJitterSource(rand.NewSource(1)))
+		defer server.Close()
+		client.retryBaseDelay = 100 * time.Millisecond
+
+		delays := map[time.Duration]bool{}
+		for i := 0; i < 10; i++ {
+			delay := client.backoff(1)
+			Expect(delay).To(BeNumerically(">=", 50*time.Millisecond))
+			Expect(delay).To(BeNumerically("<", 100*time.Millisecond))
+			delays[delay] = true
+		}
+		Expect(len(delays)).To(BeNumerically(">", 1))
+
+		delay := client.backoff(3)
+		Expect(delay).To(BeNumerically(">=", 200*time.Millisecond))
+		Expect(delay).To(BeNumerically("<", 400*time.Millisecond))
+	})
+
+	ginkgo.It("caps the delay between attempts", func() {
+		_, server, client := newFakeContextServer(WithRetryMaxDelay(3 * time.Second))
+		defer server.Close()
+		client.retryBaseDelay = time.Second
+
+		for attempt := 1; attempt <= 100; attempt++ {
+			Expect(client.backoff(attempt)).To(BeNumerically("<=", 3*time.Second), "attempt %d", attempt)
+		}
+		Expect(client.backoff(10)).To(BeNumerically(">=", 1500*time.Millisecond))
+	})
+
+	ginkgo.It("caps the delay at 30 seconds by default", func() {
+		_, server, client := newFakeContextServer()
+		defer server.Close()
+		Expect(client.backoff(64)).To(BeNumerically("<=", 30*time.Second))
+		Expect(client.backoff(64)).To(BeNumerically(">=", 15*time.Second))
+	})
+
+	ginkgo.It("spreads out concurrent retries", func() {
+		var (
+			mu       sync.Mutex
+			attempts = map[string]int{}
+			retries  []time.Time
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[req.URL.Path]++
+			if attempts[req.URL.Path] == 1 {
+				rw.WriteHeader(http.StatusTooManyRequests)
+				_, _ = rw.Write([]byte(`{"message": "slow down"}`))
+				return
+			}
+			retries = append(retries, time.Now())
+			_, _ = rw.Write([]byte(`{"message": "ok"}`))
+		}))
+		defer server.Close()
+
+		client := newTestRestClient(server, WithRetries(2), WithJitterSource(rand.NewSource(42)))
+		client.retryBaseDelay = 200 * time.Millisecond
+
+		Expect(client.BatchDeleteContexts([]string{"a", "b", "c", "d", "e"}, WithConcurrency(5))).To(Succeed())
+		Expect(retries).To(HaveLen(5))
+
+		first, last := retries[0], retries[0]
+		for _, t := range retries {
+			if t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+		Expect(last.Sub(first)).To(BeNumerically(">", 10*time.Millisecond))
+	})
+
+	ginkgo.Describe("WithRetryPredicate", func() {
+		var (
+			server   *httptest.Server
+			attempts int
+		)
+
+		ginkgo.BeforeEach(func() {
+			attempts = 0
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				attempts++
+				if attempts < 3 {
+					rw.WriteHeader(http.StatusConflict)
+					_, _ = rw.Write([]byte(`{"message": "Context is still being created"}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		stillBeingCreated := func(resp *http.Response, err error) bool {
+			if resp == nil {
+				return false
+			}
+			body, readErr := ioutil.ReadAll(resp.Body)
+			Expect(readErr).ToNot(HaveOccurred())
+			return strings.Contains(string(body), "still being created")
+		}
+
+		ginkgo.It("retries the attempts the predicate matches", func() {
+			client := newTestRestClient(server, WithRetries(3), WithRetryPredicate(stillBeingCreated))
+			client.retryBaseDelay = time.Millisecond
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(attempts).To(Equal(3))
+		})
+
+		ginkgo.It("leaves the body readable once the predicate has run", func() {
+			var seen []string
+			client := newTestRestClient(server, WithRetries(2), WithRetryPredicate(func(resp *http.Response, err error) bool {
+				body, _ := ioutil.ReadAll(resp.Body)
+				seen = append(seen, string(body))
+				return false
+			}))
+			Expect(client.DeleteContext("context-id")).To(MatchError("Context is still being created"))
+			Expect(seen).To(Equal([]string{`{"message": "Context is still being created"}`}))
+			Expect(attempts).To(Equal(1))
+		})
+
+		ginkgo.It("still retries by default", func() {
+			fake, fakeServer, client := newFakeContextServer(WithRetries(2), WithRetryPredicate(func(*http.Response, error) bool {
+				return false
+			}))
+			defer fakeServer.Close()
+			client.retryBaseDelay = time.Millisecond
+			fake.fail = func(*http.Request) int { return http.StatusServiceUnavailable }
+			Expect(client.DeleteContext("context-id")).To(MatchError("Service Unavailable"))
+			Expect(fake.Requests()).To(HaveLen(2))
+		})
+	})
+
+	ginkgo.Describe("Retry-After", func() {
+		var (
+			server   *httptest.Server
+			attempts int
+			header   string
+		)
+
+		ginkgo.BeforeEach(func() {
+			attempts = 0
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				attempts++
+				if attempts == 1 {
+					rw.Header().Set("Retry-After", header)
+					rw.WriteHeader(http.StatusServiceUnavailable)
+					_, _ = rw.Write([]byte(`{"message": "Down for maintenance"}`))
+					return
+				}
+				_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("waits as long as a 503 says instead of backing off", func() {
+			header = "0"
+			client := newTestRestClient(server, WithRetries(2))
+			client.retryBaseDelay = time.Hour
+			start := time.Now()
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(attempts).To(Equal(2))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+
+		ginkgo.It("caps the wait at the max delay", func() {
+			header = "3600"
+			client := newTestRestClient(server, WithRetries(2), WithRetryMaxDelay(10*time.Millisecond))
+			start := time.Now()
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(attempts).To(Equal(2))
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		})
+
+		ginkgo.It("parses seconds and HTTP dates on 429 and 503 only", func() {
+			now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+			response := func(status int, value string) *http.Response {
+				return &http.Response{StatusCode: status, Header: http.Header{"Retry-After": {value}}}
+			}
+
+			delay, ok := retryAfter(response(http.StatusTooManyRequests, "5"), now)
+			Expect(ok).To(BeTrue())
+			Expect(delay).To(Equal(5 * time.Second))
+
+			delay, ok = retryAfter(response(http.StatusServiceUnavailable, now.Add(time.Minute).Format(http.TimeFormat)), now)
+			Expect(ok).To(BeTrue())
+			Expect(delay).To(Equal(time.Minute))
+
+			delay, ok = retryAfter(response(http.StatusServiceUnavailable, now.Add(-time.Minute).Format(http.TimeFormat)), now)
+			Expect(ok).To(BeTrue())
+			Expect(delay).To(BeZero())
+
+			_, ok = retryAfter(response(http.StatusBadGateway, "5"), now)
+			Expect(ok).To(BeFalse())
+			_, ok = retryAfter(response(http.StatusServiceUnavailable, "soon"), now)
+			Expect(ok).To(BeFalse())
+			_, ok = retryAfter(nil, now)
+			Expect(ok).To(BeFalse())
+		})
+	})
+})
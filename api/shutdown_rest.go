@@ -0,0 +1,48 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrClientClosed is returned by requests made after CancelAll, and by those
+// which CancelAll aborted.
+var ErrClientClosed = errors.New("The client has been shut down")
+
+// CancelAll aborts every request the client has in flight, including those
+// waiting to be retried, and makes every later request fail immediately with
+// ErrClientClosed. It is meant for shutting down cleanly: the client can't be
+// used again afterwards.
+func (c *ContextRestClient) CancelAll() {
+	c.cancelAll()
+}
+
+// withClientContext returns req with a context which is also cancelled by
+// CancelAll. The returned stop function releases the context and must be
+// called once the request, and the reading of its response, are finished.
+func (c *ContextRestClient) withClientContext(req *http.Request) (*http.Request, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(req.Context())
+	go func() {
+		select {
+		case <-c.clientCtx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return req.WithContext(ctx), cancel
+}
+
+// cancelOnClose releases a request's context once its response body is
+// closed, since cancelling it any earlier would abort reading the body.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
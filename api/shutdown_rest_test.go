@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("CancelAll", func() {
+	ginkgo.It("aborts requests in flight and refuses new ones", func() {
+		started := make(chan struct{}, 3)
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			started <- struct{}{}
+			select {
+			case <-req.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		}))
+		defer server.Close()
+		client := newTestRestClient(server)
+
+		errs := make(chan error, 3)
+		for i := 0; i < 3; i++ {
+			go func() {
+				errs <- client.DeleteContext("context-id")
+			}()
+		}
+		for i := 0; i < 3; i++ {
+			Eventually(started).Should(Receive())
+		}
+
+		start := time.Now()
+		client.CancelAll()
+		for i := 0; i < 3; i++ {
+			var err error
+			Eventually(errs).Should(Receive(&err))
+			Expect(err).To(Equal(ErrClientClosed))
+		}
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+
+		Expect(client.DeleteContext("context-id")).To(Equal(ErrClientClosed))
+		Expect(started).To(BeEmpty())
+	})
+
+	ginkgo.It("aborts requests waiting to be retried", func() {
+		fake, server, client := newFakeContextServer(WithRetries(2))
+		defer server.Close()
+		client.retryBaseDelay = time.Minute
+		fake.fail = func(*http.Request) int {
+			go client.CancelAll()
+			return http.StatusServiceUnavailable
+		}
+
+		start := time.Now()
+		Expect(client.DeleteContext("context-id")).To(Equal(ErrClientClosed))
+		Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		Expect(fake.Requests()).To(HaveLen(1))
+	})
+
+	ginkgo.It("leaves responses readable until CancelAll", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		fake.addContext("ctx", time.Now(), "FOO")
+
+		contexts, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(*contexts).To(HaveLen(1))
+	})
+})
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+// A TokenProvider supplies the API token sent with each request. It is asked
+// for the token before every attempt, with the context of the request, so
+// retries pick up a rotated token and a cancelled call stops waiting for it.
+// Implementing it allows long-running programs to rotate tokens without
+// building a new client, for example by reading the current token from a
+// secrets manager.
+type TokenProvider interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenProvider which always returns the same token.
+type StaticToken string
+
+// Token returns the token itself.
+func (t StaticToken) Token(ctx context.Context) (string, error) {
+	return string(t), nil
+}
+
+// WithTokenProvider makes the client ask provider for the token to send with
+// each request, instead of always sending the token from its config.
+func WithTokenProvider(provider TokenProvider) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.token = provider
+	}
+}
+
+// WithBearerAuth makes the client send its token as an "Authorization: Bearer"
+// header, as used by OIDC tokens, instead of the default circle-token header.
+func WithBearerAuth() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.bearerAuth = true
+	}
+}
+
+// authorize sets the auth header of req to the provider's current token.
+func (c *ContextRestClient) authorize(req *http.Request) error {
+	token, err := c.token.Token(req.Context())
+	if err != nil {
+		return err
+	}
+	if c.bearerAuth {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else {
+		req.Header.Set("circle-token", token)
+	}
+	return nil
+}
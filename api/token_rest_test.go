@@ -0,0 +1,113 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type rotatingTokens struct {
+	calls     int
+	err       error
+	deadlines []bool
+}
+
+func (r *rotatingTokens) Token(ctx context.Context) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	_, hasDeadline := ctx.Deadline()
+	r.deadlines = append(r.deadlines, hasDeadline)
+	r.calls++
+	return fmt.Sprintf("token-%d", r.calls), nil
+}
+
+var _ = ginkgo.Describe("Token providers", func() {
+	var (
+		server *httptest.Server
+		tokens chan string
+		auth   chan string
+	)
+
+	ginkgo.BeforeEach(func() {
+		tokens = make(chan string, 10)
+		auth = make(chan string, 10)
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			tokens <- req.Header.Get("circle-token")
+			auth <- req.Header.Get("Authorization")
+			_, _ = rw.Write([]byte(`{"items": []}`))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("sends the configured token by default", func() {
+		client := newTestRestClient(server)
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-tokens).To(Equal("token"))
+	})
+
+	ginkgo.It("asks the provider for the current token on each request", func() {
+		client := newTestRestClient(server, WithTokenProvider(&rotatingTokens{}))
+		for _, expected := range []string{"token-1", "token-2", "token-3"} {
+			_, err := client.Contexts("gh", "test-org")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(<-tokens).To(Equal(expected))
+		}
+	})
+
+	ginkgo.It("fails the request if the provider fails", func() {
+		client := newTestRestClient(server, WithTokenProvider(&rotatingTokens{err: errors.New("vault is sealed")}))
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).To(MatchError("vault is sealed"))
+		Expect(tokens).To(BeEmpty())
+	})
+	ginkgo.It("sends the token in the circle-token header by default", func() {
+		client := newTestRestClient(server)
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-tokens).To(Equal("token"))
+		Expect(<-auth).To(BeEmpty())
+	})
+
+	ginkgo.It("can send the token as a bearer token", func() {
+		client := newTestRestClient(server, WithBearerAuth())
+		_, err := client.Contexts("gh", "test-org")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-tokens).To(BeEmpty())
+		Expect(<-auth).To(Equal("Bearer token"))
+	})
+
+	ginkgo.It("asks the provider again on each attempt, with the call's context", func() {
+		attempts := 0
+		retrying := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			attempts++
+			tokens <- req.Header.Get("circle-token")
+			if attempts == 1 {
+				rw.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			_, _ = rw.Write([]byte(`{"id": "org-id"}`))
+		}))
+		defer retrying.Close()
+
+		provider := &rotatingTokens{}
+		client := newTestRestClient(retrying, WithTokenProvider(provider), WithRetries(2))
+		client.retryBaseDelay = time.Millisecond
+		_, err := client.GetOrganization("gh", "test-org", WithCallTimeout(time.Minute))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(<-tokens).To(Equal("token-1"))
+		Expect(<-tokens).To(Equal("token-2"))
+		Expect(provider.deadlines).To(Equal([]bool{true, true}))
+	})
+})
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+	"golang.org/x/net/http2"
+)
+
+// WithHTTPClient makes the client send its requests with httpClient, as is,
+// instead of the one from its config. Options which tune the transport, such
+// as WithMaxIdleConns, are ignored for it: configure its transport directly
+// instead. They do apply to the http.Client of the config, such as the CLI's,
+// when its transport is an *http.Transport, which is cloned rather than
+// modified.
+func WithHTTPClient(httpClient *http.Client) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.client = httpClient
+		c.customClient = true
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle connections, across all
+// hosts, kept open by the client's transport, unless it was given
+// WithHTTPClient. Raising it reduces connection churn in high-throughput
+// batch tools.
+func WithMaxIdleConns(n int) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.maxIdleConns = n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle connections to each
+// host kept open by the client's transport, unless it was given
+// WithHTTPClient. Since the client talks to a single host, this is usually the
+// limit that matters; Go's default is only 2.
+func WithMaxIdleConnsPerHost(n int) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.maxIdleConnsPerHost = n
+	}
+}
+
+// WithHTTP2ReadIdleTimeout makes the client's transport, unless it was given
+// WithHTTPClient, send a health check ping on an HTTP/2 connection which has
+// received nothing for the given time. Along with WithHTTP2PingTimeout, this
+// lets long-running pollers detect and replace dead connections, rather than
+// stalling on them. It only applies to connections which negotiate HTTP/2.
+func WithHTTP2ReadIdleTimeout(timeout time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.http2ReadIdleTimeout = timeout
+	}
+}
+
+// WithHTTP2PingTimeout sets how long the transport waits for the response to
+// a health check ping, sent as configured by WithHTTP2ReadIdleTimeout, before
+// closing the connection. It only applies to connections which negotiate
+// HTTP/2.
+func WithHTTP2PingTimeout(timeout time.Duration) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.http2PingTimeout = timeout
+	}
+}
+
+// WithMinTLSVersion sets the oldest TLS version, such as tls.VersionTLS13,
+// which the client's transport will negotiate, unless it was given
+// WithHTTPClient. The default is TLS 1.2.
+func WithMinTLSVersion(version uint16) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.minTLSVersion = version
+	}
+}
+
+// WithNoProxy makes the client's transport, unless it was given
+// WithHTTPClient, connect to the API directly, ignoring any proxy configured
+// by the environment.
+func WithNoProxy() ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.noProxy = true
+	}
+}
+
+// WithDialContext makes the client's transport, unless it was given
+// WithHTTPClient, open its connections with dial, instead of resolving the
+// API's host with the system's DNS. This suits split-horizon DNS, or tests
+// connecting to a local listener. Connections to a proxy are dialled with it
+// too.
+func WithDialContext(dial func(ctx context.Context, network, addr string) (net.Conn, error)) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.dialContext = dial
+	}
+}
+
+// newHTTPClient builds the http.Client used when none has been provided, from
+// a copy of http.DefaultTransport tuned by the client's transport options.
+func (c *ContextRestClient) newHTTPClient() (*http.Client, error) {
+	return c.tuneHTTPClient(&http.Client{Transport: http.DefaultTransport})
+}
+
+// tuneHTTPClient returns a copy of httpClient whose transport, if it is an
+// *http.Transport, such as the one built by settings.Config.WithHTTPClient
+// for the CLI, is replaced by a clone tuned by the client's transport
+// options. Other transports are left as they are. Unless WithNoProxy is
+// given, the transport sends requests through the proxy configured by the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables, as read when
+// the client is created.
+func (c *ContextRestClient) tuneHTTPClient(httpClient *http.Client) (*http.Client, error) {
+	base, ok := httpClient.Transport.(*http.Transport)
+	if !ok {
+		return httpClient, nil
+	}
+	transport := base.Clone()
+
+	tlsConfig := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		tlsConfig = transport.TLSClientConfig.Clone()
+	}
+	if tlsConfig.MinVersion < c.minTLSVersion {
+		tlsConfig.MinVersion = c.minTLSVersion
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	transport.Proxy = nil
+	if !c.noProxy {
+		proxy := httpproxy.FromEnvironment().ProxyFunc()
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxy(req.URL)
+		}
+	}
+	if c.dialContext != nil {
+		transport.DialContext = c.dialContext
+	}
+	if c.maxIdleConns > 0 {
+		transport.MaxIdleConns = c.maxIdleConns
+	}
+	if c.maxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = c.maxIdleConnsPerHost
+	}
+
+	if c.http2ReadIdleTimeout > 0 || c.http2PingTimeout > 0 {
+		h2, err := c.configureHTTP2(transport)
+		if err != nil {
+			return nil, err
+		}
+		c.http2Transport = h2
+	}
+
+	tuned := *httpClient
+	tuned.Transport = transport
+	return &tuned, nil
+}
+
+// configureHTTP2 replaces the HTTP/2 support of transport with one tuned by
+// the client's HTTP/2 options.
+func (c *ContextRestClient) configureHTTP2(transport *http.Transport) (*http2.Transport, error) {
+	// A clone of the default transport may have inherited its HTTP/2
+	// support, which can't be tuned.
+	transport.TLSNextProto = nil
+	h2, err := http2.ConfigureTransports(transport)
+	if err != nil {
+		return nil, err
+	}
+	h2.ReadIdleTimeout = c.http2ReadIdleTimeout
+	h2.PingTimeout = c.http2PingTimeout
+	return h2, nil
+}
@@ -0,0 +1,247 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"time"
+
+	"github.com/CircleCI-Public/circleci-cli/settings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Transport options", func() {
+	newClient := func(httpClient *http.Client, opts ...ContextRestOption) *ContextRestClient {
+		client, err := NewContextRestClient(settings.Config{
+			Host:       "https://circleci.com",
+			Endpoint:   "api/v2",
+			Token:      "token",
+			HTTPClient: httpClient,
+		}, opts...)
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	ginkgo.It("tunes the transport the client creates", func() {
+		client := newClient(nil, WithMaxIdleConns(200), WithMaxIdleConnsPerHost(50))
+		transport, ok := client.client.Transport.(*http.Transport)
+		Expect(ok).To(BeTrue())
+		Expect(transport.MaxIdleConns).To(Equal(200))
+		Expect(transport.MaxIdleConnsPerHost).To(Equal(50))
+		Expect(http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost).ToNot(Equal(50))
+	})
+
+	ginkgo.It("keeps Go's defaults when no limits are set", func() {
+		client := newClient(nil)
+		transport := client.client.Transport.(*http.Transport)
+		Expect(transport.MaxIdleConns).To(Equal(http.DefaultTransport.(*http.Transport).MaxIdleConns))
+	})
+
+	ginkgo.It("ignores the limits when given an http.Client", func() {
+		custom := &http.Transport{MaxIdleConns: 7}
+		client := newClient(nil, WithHTTPClient(&http.Client{Transport: custom}), WithMaxIdleConns(200))
+		Expect(client.client.Transport).To(BeIdenticalTo(custom))
+		Expect(custom.MaxIdleConns).To(Equal(7))
+	})
+
+	ginkgo.It("prefers WithHTTPClient over the config's client", func() {
+		custom := &http.Transport{}
+		client := newClient(&http.Client{}, WithHTTPClient(&http.Client{Transport: custom}))
+		Expect(client.client.Transport).To(BeIdenticalTo(custom))
+	})
+
+	ginkgo.It("tunes HTTP/2 health checks on the transport the client creates", func() {
+		client := newClient(nil, WithHTTP2ReadIdleTimeout(30*time.Second), WithHTTP2PingTimeout(5*time.Second))
+		transport := client.client.Transport.(*http.Transport)
+		Expect(transport.TLSNextProto).To(HaveKey("h2"))
+		Expect(client.http2Transport).ToNot(BeNil())
+		Expect(client.http2Transport.ReadIdleTimeout).To(Equal(30 * time.Second))
+		Expect(client.http2Transport.PingTimeout).To(Equal(5 * time.Second))
+	})
+
+	ginkgo.It("leaves HTTP/2 untuned by default", func() {
+		Expect(newClient(nil).http2Transport).To(BeNil())
+	})
+	ginkgo.It("requires TLS 1.2 by default", func() {
+		client := newClient(nil)
+		transport := client.client.Transport.(*http.Transport)
+		Expect(transport.TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+	})
+
+	ginkgo.It("sets the minimum TLS version of the transport the client creates", func() {
+		client := newClient(nil, WithMinTLSVersion(tls.VersionTLS13))
+		transport := client.client.Transport.(*http.Transport)
+		Expect(transport.TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS13)))
+	})
+
+	ginkgo.It("ignores the minimum TLS version when given an http.Client", func() {
+		custom := &http.Transport{}
+		client := newClient(nil, WithHTTPClient(&http.Client{Transport: custom}), WithMinTLSVersion(tls.VersionTLS13))
+		Expect(client.client.Transport).To(BeIdenticalTo(custom))
+		Expect(custom.TLSClientConfig).To(BeNil())
+	})
+
+	ginkgo.It("tunes a clone of the CLI's transport", func() {
+		config := settings.Config{TLSInsecure: true}
+		Expect(config.WithHTTPClient()).To(Succeed())
+		cliTransport := config.HTTPClient.Transport.(*http.Transport)
+
+		dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, nil
+		}
+		client := newClient(config.HTTPClient, WithMinTLSVersion(tls.VersionTLS13), WithMaxIdleConnsPerHost(50), WithDialContext(dial),
+			WithHTTP2ReadIdleTimeout(30*time.Second))
+		transport := client.client.Transport.(*http.Transport)
+		Expect(transport).ToNot(BeIdenticalTo(cliTransport))
+		Expect(transport.TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS13)))
+		Expect(transport.TLSClientConfig.InsecureSkipVerify).To(BeTrue())
+		Expect(transport.MaxIdleConnsPerHost).To(Equal(50))
+		Expect(transport.DialContext).ToNot(BeNil())
+		Expect(transport.TLSNextProto).To(HaveKey("h2"))
+		Expect(client.client.Timeout).To(Equal(config.HTTPClient.Timeout))
+
+		Expect(cliTransport.TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+		Expect(cliTransport.DialContext).To(BeNil())
+	})
+
+	ginkgo.It("requires TLS 1.2 of the CLI's transport", func() {
+		var config settings.Config
+		Expect(config.WithHTTPClient()).To(Succeed())
+		Expect(config.HTTPClient.Transport.(*http.Transport).TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+
+		client := newClient(config.HTTPClient)
+		Expect(client.client.Transport.(*http.Transport).TLSClientConfig.MinVersion).To(Equal(uint16(tls.VersionTLS12)))
+	})
+
+	ginkgo.It("opens connections with the given dialer", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+		}))
+		defer server.Close()
+
+		var dialled []string
+		client, err := NewContextRestClient(settings.Config{
+			Host:     "http://circleci.internal.example",
+			Endpoint: "api/v2",
+			Token:    "token",
+		}, WithNoProxy(), WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialled = append(dialled, addr)
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+		}))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(client.DeleteContext("context-id")).To(Succeed())
+		Expect(dialled).To(Equal([]string{"circleci.internal.example:80"}))
+	})
+
+	ginkgo.It("ignores the dialer when given an http.Client", func() {
+		custom := &http.Transport{}
+		client := newClient(nil, WithHTTPClient(&http.Client{Transport: custom}), WithDialContext(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, nil
+		}))
+		Expect(client.client.Transport).To(BeIdenticalTo(custom))
+		Expect(custom.DialContext).To(BeNil())
+	})
+
+	ginkgo.Describe("proxies", func() {
+		proxyVariables := []string{"HTTP_PROXY", "http_proxy", "HTTPS_PROXY", "https_proxy", "NO_PROXY", "no_proxy"}
+		var saved map[string]*string
+
+		ginkgo.BeforeEach(func() {
+			saved = map[string]*string{}
+			for _, name := range proxyVariables {
+				if value, ok := os.LookupEnv(name); ok {
+					saved[name] = &value
+				} else {
+					saved[name] = nil
+				}
+				Expect(os.Unsetenv(name)).To(Succeed())
+			}
+		})
+
+		ginkgo.AfterEach(func() {
+			for name, value := range saved {
+				if value != nil {
+					Expect(os.Setenv(name, *value)).To(Succeed())
+				} else {
+					Expect(os.Unsetenv(name)).To(Succeed())
+				}
+			}
+		})
+
+		ginkgo.It("sends requests through the proxy from the environment", func() {
+			var proxied []string
+			proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				proxied = append(proxied, req.Method+" "+req.URL.String())
+				_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+			}))
+			defer proxy.Close()
+			Expect(os.Setenv("HTTP_PROXY", proxy.URL)).To(Succeed())
+
+			client, err := NewContextRestClient(settings.Config{
+				Host:     "http://circleci.example",
+				Endpoint: "api/v2",
+				Token:    "token",
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(proxied).To(Equal([]string{"DELETE http://circleci.example/api/v2/context/context-id"}))
+		})
+
+		ginkgo.It("sends the CLI's requests through the proxy from the environment", func() {
+			var proxied []string
+			proxy := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				proxied = append(proxied, req.Method+" "+req.URL.String())
+				_, _ = rw.Write([]byte(`{"message": "Context deleted."}`))
+			}))
+			defer proxy.Close()
+			Expect(os.Setenv("HTTP_PROXY", proxy.URL)).To(Succeed())
+
+			config := settings.Config{
+				Host:     "http://circleci.example",
+				Endpoint: "api/v2",
+				Token:    "token",
+			}
+			Expect(config.WithHTTPClient()).To(Succeed())
+			client, err := NewContextRestClient(config)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(client.DeleteContext("context-id")).To(Succeed())
+			Expect(proxied).To(Equal([]string{"DELETE http://circleci.example/api/v2/context/context-id"}))
+		})
+
+		ginkgo.It("lets NO_PROXY exclude the API from the CLI's proxy", func() {
+			Expect(os.Setenv("HTTPS_PROXY", "http://proxy.example:3128")).To(Succeed())
+			Expect(os.Setenv("NO_PROXY", "circleci.com")).To(Succeed())
+			var config settings.Config
+			Expect(config.WithHTTPClient()).To(Succeed())
+			transport := config.HTTPClient.Transport.(*http.Transport)
+			req, err := http.NewRequest("GET", "https://circleci.com/api/v2/me", nil)
+			Expect(err).ToNot(HaveOccurred())
+			proxyURL, err := transport.Proxy(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(proxyURL).To(BeNil())
+		})
+
+		ginkgo.It("uses the HTTPS proxy for the API", func() {
+			Expect(os.Setenv("HTTPS_PROXY", "http://proxy.example:3128")).To(Succeed())
+			transport := newClient(nil).client.Transport.(*http.Transport)
+			req, err := http.NewRequest("GET", "https://circleci.com/api/v2/me", nil)
+			Expect(err).ToNot(HaveOccurred())
+			proxyURL, err := transport.Proxy(req)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(proxyURL.String()).To(Equal("http://proxy.example:3128"))
+		})
+
+		ginkgo.It("connects directly WithNoProxy", func() {
+			Expect(os.Setenv("HTTPS_PROXY", "http://proxy.example:3128")).To(Succeed())
+			transport := newClient(nil, WithNoProxy()).client.Transport.(*http.Transport)
+			Expect(transport.Proxy).To(BeNil())
+		})
+	})
+})
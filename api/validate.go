@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// MaxContextNameLength is the longest context name CircleCI accepts.
+const MaxContextNameLength = 200
+
+// MaxEnvVarValueLength is the longest environment variable value, in bytes,
+// CircleCI accepts.
+const MaxEnvVarValueLength = 32 * 1024
+
+var contextNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.\- ]+$`)
+
+var envVarNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateContextName checks name against CircleCI's rules for context names:
+// it must not be empty, must be at most MaxContextNameLength characters long,
+// must not start or end with a space, and may only contain letters, digits,
+// spaces, dots, dashes and underscores.
+func ValidateContextName(name string) error {
+	if name == "" {
+		return errors.New("Context name must not be empty")
+	}
+	if len(name) > MaxContextNameLength {
+		return fmt.Errorf("Context name must be at most %d characters long", MaxContextNameLength)
+	}
+	if strings.TrimSpace(name) != name {
+		return fmt.Errorf("Context name '%s' must not start or end with a space", name)
+	}
+	if !contextNamePattern.MatchString(name) {
+		return fmt.Errorf("Context name '%s' may only contain letters, digits, spaces, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// ValidateEnvVarName checks that name is a valid shell identifier, as CircleCI
+// requires of environment variable names: it must start with a letter or an
+// underscore, and may only contain letters, digits and underscores.
+func ValidateEnvVarName(name string) error {
+	if name == "" {
+		return errors.New("Environment variable name must not be empty")
+	}
+	if !envVarNamePattern.MatchString(name) {
+		return fmt.Errorf("Environment variable name '%s' must start with a letter or '_', and may only contain letters, digits and '_'", name)
+	}
+	return nil
+}
+
+// ValidateEnvVarValue checks that value is no longer than MaxEnvVarValueLength
+// bytes. The error never includes the value, which may be a secret.
+func ValidateEnvVarValue(value string) error {
+	if len(value) > MaxEnvVarValueLength {
+		return fmt.Errorf("Environment variable value is %d bytes long, but must be at most %d", len(value), MaxEnvVarValueLength)
+	}
+	return nil
+}
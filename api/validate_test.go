@@ -0,0 +1,102 @@
+package api
+
+import (
+	"strings"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Validation", func() {
+	ginkgo.Describe("ValidateContextName", func() {
+		ginkgo.It("accepts valid names", func() {
+			for _, name := range []string{"org-global", "my_context", "Deploy Keys", "v1.2", strings.Repeat("a", MaxContextNameLength)} {
+				Expect(ValidateContextName(name)).To(Succeed(), name)
+			}
+		})
+
+		ginkgo.It("rejects empty names", func() {
+			Expect(ValidateContextName("")).To(MatchError("Context name must not be empty"))
+		})
+
+		ginkgo.It("rejects names which are too long", func() {
+			Expect(ValidateContextName(strings.Repeat("a", MaxContextNameLength+1))).To(MatchError("Context name must be at most 200 characters long"))
+		})
+
+		ginkgo.It("rejects names with bad characters", func() {
+			for _, name := range []string{"a/b", "semi;colon", "new\nline", "emoji-🔑"} {
+				Expect(ValidateContextName(name)).To(HaveOccurred(), name)
+			}
+			Expect(ValidateContextName(" padded ")).To(MatchError("Context name ' padded ' must not start or end with a space"))
+		})
+
+		ginkgo.It("is checked before creating a context", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			Expect(client.CreateContext("gh", "test-org", "a/b")).To(MatchError("Context name 'a/b' may only contain letters, digits, spaces, '.', '-' and '_'"))
+			Expect(fake.Requests()).To(BeEmpty())
+		})
+	})
+	ginkgo.Describe("ValidateEnvVarName", func() {
+		ginkgo.It("accepts valid names", func() {
+			for _, name := range []string{"A", "_", "AWS_ACCESS_KEY_ID", "_private", "lower_case", "V2"} {
+				Expect(ValidateEnvVarName(name)).To(Succeed(), name)
+			}
+		})
+
+		ginkgo.It("rejects empty names", func() {
+			Expect(ValidateEnvVarName("")).To(MatchError("Environment variable name must not be empty"))
+		})
+
+		ginkgo.It("rejects names which aren't shell identifiers", func() {
+			for _, name := range []string{"2FA", "MY-VAR", "with space", "dotted.name", "A=B", "ÜBER"} {
+				Expect(ValidateEnvVarName(name)).To(HaveOccurred(), name)
+			}
+			Expect(ValidateEnvVarName("API-KEY")).To(MatchError("Environment variable name 'API-KEY' must start with a letter or '_', and may only contain letters, digits and '_'"))
+		})
+
+		ginkgo.It("is checked before creating a variable", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			Expect(client.CreateEnvironmentVariable("context-id", "API-KEY", "secret")).To(HaveOccurred())
+			Expect(fake.Requests()).To(BeEmpty())
+		})
+	})
+	ginkgo.Describe("ValidateEnvVarValue", func() {
+		ginkgo.It("accepts values up to the limit", func() {
+			Expect(ValidateEnvVarValue("")).To(Succeed())
+			Expect(ValidateEnvVarValue(strings.Repeat("a", MaxEnvVarValueLength))).To(Succeed())
+		})
+
+		ginkgo.It("rejects values over the limit without including them", func() {
+			err := ValidateEnvVarValue(strings.Repeat("s", MaxEnvVarValueLength+1))
+			Expect(err).To(MatchError("Environment variable value is 32769 bytes long, but must be at most 32768"))
+		})
+
+		ginkgo.It("is checked before creating a variable", func() {
+			fake, server, client := newFakeContextServer()
+			defer server.Close()
+			id := fake.addContext("ctx", time.Now())
+			Expect(client.CreateEnvironmentVariable(id, "AT_LIMIT", strings.Repeat("a", MaxEnvVarValueLength))).To(Succeed())
+			Expect(fake.Requests()).To(HaveLen(1))
+
+			Expect(client.CreateEnvironmentVariable(id, "OVER_LIMIT", strings.Repeat("a", MaxEnvVarValueLength+1))).ToNot(Succeed())
+			Expect(fake.Requests()).To(HaveLen(1))
+		})
+	})
+	ginkgo.Describe("ValidateReportingWindow", func() {
+		ginkgo.It("accepts each of the valid windows", func() {
+			for _, window := range ReportingWindows {
+				Expect(ValidateReportingWindow(window)).To(Succeed(), string(window))
+			}
+			Expect(ValidateReportingWindow("last-7-days")).To(Succeed())
+		})
+
+		ginkgo.It("rejects other windows", func() {
+			Expect(ValidateReportingWindow("last-week")).To(MatchError("Invalid reporting window 'last-week': expected one of last-24-hours, last-7-days, last-30-days, last-60-days, last-90-days"))
+			Expect(ValidateReportingWindow("")).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+)
+
+// APIVersion returns the version of the API, as reported by the info.version
+// field of its OpenAPI document. Both CircleCI cloud and CircleCI Server
+// serve the document, so this can be used to enable features depending on the
+// installation being talked to.
+func (c *ContextRestClient) APIVersion() (string, error) {
+	req, err := c.newOpenAPIRequest()
+	if err != nil {
+		return "", err
+	}
+
+	resp, bodyBytes, err := c.send(context.Background(), req, nil, nil)
+	if err != nil && resp != nil {
+		return "", errors.Wrap(err, "The API version is unavailable")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var dest struct {
+		Info struct {
+			Version string `json:"version"`
+		} `json:"info"`
+	}
+	// Like EnsureExists, only a tiny part of the document is of interest, so
+	// it is never decoded strictly.
+	if err := decodeBody(resp.StatusCode, bodyBytes, &dest, false); err != nil {
+		return "", err
+	}
+	if dest.Info.Version == "" {
+		return "", errors.New("The API does not report its version")
+	}
+	return dest.Info.Version, nil
+}
+
+func (c *ContextRestClient) newOpenAPIRequest() (*http.Request, error) {
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse("openapi.json")
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
+}
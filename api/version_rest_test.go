@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("APIVersion", func() {
+	var (
+		server   *httptest.Server
+		status   int
+		document string
+	)
+
+	ginkgo.BeforeEach(func() {
+		status = http.StatusOK
+		server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			defer ginkgo.GinkgoRecover()
+			Expect(req.URL.Path).To(Equal("/api/v2/openapi.json"))
+			rw.WriteHeader(status)
+			_, _ = rw.Write([]byte(document))
+		}))
+	})
+
+	ginkgo.AfterEach(func() {
+		server.Close()
+	})
+
+	ginkgo.It("returns the version from the OpenAPI document", func() {
+		document = `{"openapi": "3.0.3", "info": {"title": "CircleCI API", "version": "v2"}, "paths": {}}`
+		version, err := newTestRestClient(server).APIVersion()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).To(Equal("v2"))
+	})
+
+	ginkgo.It("requires the document to include a version", func() {
+		document = `{"openapi": "3.0.3", "info": {"title": "CircleCI API"}}`
+		_, err := newTestRestClient(server).APIVersion()
+		Expect(err).To(MatchError("The API does not report its version"))
+	})
+
+	ginkgo.It("explains when the document is unavailable", func() {
+		status = http.StatusNotFound
+		document = `{"message": "Not found."}`
+		_, err := newTestRestClient(server).APIVersion()
+		Expect(err).To(MatchError("The API version is unavailable: Not found."))
+	})
+})
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WaitForContextVariables polls the environment variables of the context
+// every pollInterval until every one of the expected names is present. This
+// allows for eventual consistency after variables have been created in bulk.
+// Bound the wait with a deadline on ctx: once ctx is done, the returned error
+// lists the names which are still missing and wraps ctx.Err(). Errors listing
+// the variables are returned immediately.
+func (c *ContextRestClient) WaitForContextVariables(ctx context.Context, contextID string, expected []string, pollInterval time.Duration) error {
+	for {
+		envVars, err := c.listAllEnvironmentVariables(ctx, &listEnvironmentVariablesParams{
+			ContextID: &contextID,
+		})
+		if err != nil && ctx.Err() == nil {
+			return err
+		}
+		missing := missingVariables(envVars, expected)
+		if err == nil && len(missing) == 0 {
+			return nil
+		}
+
+		if ctx.Err() == nil {
+			select {
+			case <-c.after(pollInterval):
+				continue
+			case <-ctx.Done():
+			}
+		}
+		return fmt.Errorf("Timed out waiting for context '%s' to have the variables %s: %w",
+			contextID, strings.Join(missing, ", "), ctx.Err())
+	}
+}
+
+func missingVariables(envVars []EnvironmentVariable, expected []string) []string {
+	present := make(map[string]bool, len(envVars))
+	for _, envVar := range envVars {
+		present[envVar.Variable] = true
+	}
+
+	var missing []string
+	for _, name := range expected {
+		if !present[name] {
+			missing = append(missing, name)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("WaitForContextVariables", func() {
+	ginkgo.It("polls until every expected variable is present", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		id := fake.addContext("ctx", time.Now(), "A")
+
+		// Each tick of the fake clock makes one more variable visible.
+		pending := []string{"B", "C"}
+		var waits []time.Duration
+		client.after = func(d time.Duration) <-chan time.Time {
+			waits = append(waits, d)
+			fake.mu.Lock()
+			fake.envVars[id] = append(fake.envVars[id], EnvironmentVariable{Variable: pending[0], ContextID: id})
+			fake.mu.Unlock()
+			pending = pending[1:]
+
+			tick := make(chan time.Time, 1)
+			tick <- time.Now()
+			return tick
+		}
+
+		err := client.WaitForContextVariables(context.Background(), id, []string{"A", "B", "C"}, time.Minute)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(waits).To(Equal([]time.Duration{time.Minute, time.Minute}))
+	})
+
+	ginkgo.It("does not wait when the variables are already present", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		id := fake.addContext("ctx", time.Now(), "A", "B")
+		client.after = func(time.Duration) <-chan time.Time {
+			ginkgo.Fail("unexpected wait")
+			return nil
+		}
+
+		Expect(client.WaitForContextVariables(context.Background(), id, []string{"B", "A"}, time.Minute)).To(Succeed())
+	})
+
+	ginkgo.It("lists the missing variables once the context is done", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		id := fake.addContext("ctx", time.Now(), "A")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		client.after = func(time.Duration) <-chan time.Time {
+			// Time runs out before the next tick.
+			cancel()
+			return nil
+		}
+
+		err := client.WaitForContextVariables(ctx, id, []string{"A", "B", "C"}, time.Minute)
+		Expect(err).To(MatchError("Timed out waiting for context '" + id + "' to have the variables B, C: context canceled"))
+		Expect(errors.Is(err, context.Canceled)).To(BeTrue())
+	})
+
+	ginkgo.It("returns errors listing the variables", func() {
+		fake, server, client := newFakeContextServer()
+		defer server.Close()
+		fake.fail = func(*http.Request) int { return http.StatusForbidden }
+		client.after = func(time.Duration) <-chan time.Time {
+			ginkgo.Fail("unexpected wait")
+			return nil
+		}
+
+		err := client.WaitForContextVariables(context.Background(), "context-id", []string{"A"}, time.Minute)
+		Expect(err).To(MatchError("Forbidden"))
+	})
+})
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// WebhookSignatureHeader is the header CircleCI signs webhook payloads in.
+const WebhookSignatureHeader = "circleci-signature"
+
+// VerifyWebhookSignature reports whether body was signed with secret, given
+// the value of the WebhookSignatureHeader that it was delivered with. The
+// header holds comma-separated "scheme=signature" pairs; CircleCI currently
+// signs with the v1 scheme, the hex-encoded HMAC-SHA256 of the body, and
+// other schemes are ignored. The signatures are compared in constant time. An
+// error is returned if the header holds no well-formed v1 signature.
+func VerifyWebhookSignature(secret string, body []byte, signatureHeader string) (bool, error) {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	found := false
+	valid := false
+	for _, pair := range strings.Split(signatureHeader, ",") {
+		scheme, signature, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || scheme != "v1" {
+			continue
+		}
+		decoded, err := hex.DecodeString(signature)
+		if err != nil {
+			return false, fmt.Errorf("Malformed v1 webhook signature: %s", err)
+		}
+		found = true
+		// Check every v1 signature, rather than stopping at the first match,
+		// so that the time taken doesn't depend on which one matches.
+		if hmac.Equal(decoded, expected) {
+			valid = true
+		}
+	}
+	if !found {
+		return false, errors.New("The webhook signature header has no v1 signature")
+	}
+	return valid, nil
+}
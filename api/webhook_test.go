@@ -0,0 +1,50 @@
+package api
+
+import (
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("VerifyWebhookSignature", func() {
+	body := []byte(`{"type":"workflow-completed"}`)
+	signature := "8706d9ce1500dc0fd98e3a6caa8d389ac1d6b55079027639231c3e2425065da3"
+
+	ginkgo.It("accepts a valid v1 signature", func() {
+		valid, err := VerifyWebhookSignature("secret", body, "v1="+signature)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(BeTrue())
+
+		valid, err = VerifyWebhookSignature("key", []byte("The quick brown fox jumps over the lazy dog"),
+			"v1=f7bc83f430538424b13298e6aa6fb143ef4d59a14946175997479dbc2d1a3cd8")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(BeTrue())
+	})
+
+	ginkgo.It("accepts a valid signature among several", func() {
+		valid, err := VerifyWebhookSignature("secret", body, "v2=abc, v1=00ff, v1="+signature)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(BeTrue())
+	})
+
+	ginkgo.It("rejects signatures made with another secret or body", func() {
+		valid, err := VerifyWebhookSignature("other-secret", body, "v1="+signature)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(BeFalse())
+
+		valid, err = VerifyWebhookSignature("secret", []byte(`{"type":"job-completed"}`), "v1="+signature)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(valid).To(BeFalse())
+	})
+
+	ginkgo.It("returns an error for malformed headers", func() {
+		_, err := VerifyWebhookSignature("secret", body, "")
+		Expect(err).To(MatchError("The webhook signature header has no v1 signature"))
+
+		_, err = VerifyWebhookSignature("secret", body, "v2="+signature)
+		Expect(err).To(MatchError("The webhook signature header has no v1 signature"))
+
+		_, err = VerifyWebhookSignature("secret", body, "v1=not-hex")
+		Expect(err).To(MatchError(HavePrefix("Malformed v1 webhook signature")))
+	})
+})
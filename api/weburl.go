@@ -0,0 +1,55 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const cloudAppURL = "https://app.circleci.com"
+
+// WithAppURL sets the base URL of the CircleCI web app, which ContextWebURL
+// links to. It is only needed for CircleCI Server installations whose app
+// isn't served from the same host as the API.
+func WithAppURL(appURL string) ContextRestOption {
+	return func(c *ContextRestClient) {
+		c.appURL = strings.TrimSuffix(appURL, "/")
+	}
+}
+
+// ContextWebURL returns the URL of the page in the CircleCI web app for
+// managing the given context. It is derived without calling the API. Unless
+// configured WithAppURL, the app is assumed to be at app.circleci.com for
+// CircleCI cloud, and at the root of the API's host for CircleCI Server.
+func (c *ContextRestClient) ContextWebURL(vcs, org, contextID string) string {
+	return fmt.Sprintf("%s/settings/organization/%s/%s/contexts/%s",
+		c.webAppURL(),
+		url.PathEscape(webVCS(vcs)),
+		url.PathEscape(org),
+		url.PathEscape(contextID))
+}
+
+func (c *ContextRestClient) webAppURL() string {
+	if c.appURL != "" {
+		return c.appURL
+	}
+	serverURL, err := url.Parse(c.server)
+	if err != nil || serverURL.Host == "circleci.com" {
+		return cloudAppURL
+	}
+	return fmt.Sprintf("%s://%s", serverURL.Scheme, serverURL.Host)
+}
+
+// webVCS returns the name the web app uses in URLs for the given vcs.
+func webVCS(vcs string) string {
+	switch strings.ToLower(vcs) {
+	case "gh", "github":
+		return "github"
+	case "bb", "bitbucket":
+		return "bitbucket"
+	}
+	if isGitLab(vcs) {
+		return "circleci"
+	}
+	return vcs
+}
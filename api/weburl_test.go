@@ -0,0 +1,43 @@
+package api
+
+import (
+	"github.com/CircleCI-Public/circleci-cli/settings"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("ContextWebURL", func() {
+	newClient := func(host string, opts ...ContextRestOption) *ContextRestClient {
+		client, err := NewContextRestClient(settings.Config{
+			Host:     host,
+			Endpoint: "api/v2",
+			Token:    "token",
+		}, opts...)
+		Expect(err).ToNot(HaveOccurred())
+		return client
+	}
+
+	ginkgo.It("links to app.circleci.com for CircleCI cloud", func() {
+		client := newClient("https://circleci.com")
+		Expect(client.ContextWebURL("gh", "test-org", "context-id")).To(
+			Equal("https://app.circleci.com/settings/organization/github/test-org/contexts/context-id"))
+		Expect(client.ContextWebURL("bitbucket", "test-org", "context-id")).To(
+			Equal("https://app.circleci.com/settings/organization/bitbucket/test-org/contexts/context-id"))
+		Expect(client.ContextWebURL("gitlab", "org-id", "context-id")).To(
+			Equal("https://app.circleci.com/settings/organization/circleci/org-id/contexts/context-id"))
+	})
+
+	ginkgo.It("links to the API's host for CircleCI Server", func() {
+		client := newClient("https://circleci.example.com")
+		Expect(client.ContextWebURL("gh", "test-org", "context-id")).To(
+			Equal("https://circleci.example.com/settings/organization/github/test-org/contexts/context-id"))
+	})
+
+	ginkgo.It("links to the configured app URL", func() {
+		client := newClient("https://api.example.com", WithAppURL("https://app.example.com/"))
+		Expect(client.ContextWebURL("gh", "test org", "context-id")).To(
+			Equal("https://app.example.com/settings/organization/github/test%20org/contexts/context-id"))
+	})
+})
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"time"
+)
+
+// A Workflow is a set of jobs run as part of a Pipeline.
+type Workflow struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	PipelineID     string `json:"pipeline_id"`
+	PipelineNumber int    `json:"pipeline_number"`
+	ProjectSlug    string `json:"project_slug"`
+	Status         string `json:"status"`
+	StartedBy      string `json:"started_by"`
+	CanceledBy     string `json:"canceled_by,omitempty"`
+	ErroredBy      string `json:"errored_by,omitempty"`
+	// Tag marks special workflows, such as "setup" for the setup workflow of
+	// a dynamic configuration.
+	Tag       string     `json:"tag,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	StoppedAt *time.Time `json:"stopped_at"`
+	// AutoRerunNumber is set when the workflow is an automatic rerun of an
+	// earlier, failed run, counting up from 1. The API does not link manual
+	// reruns to the workflow they were rerun from.
+	AutoRerunNumber *int `json:"auto_rerun_number,omitempty"`
+	MaxAutoReruns   *int `json:"max_auto_reruns,omitempty"`
+}
+
+// IsRerun reports whether the workflow is an automatic rerun of an earlier
+// run.
+func (w Workflow) IsRerun() bool {
+	return w.AutoRerunNumber != nil && *w.AutoRerunNumber > 0
+}
+
+// A PipelineTree is the workflows of a pipeline, each with its jobs, as
+// returned by GetPipelineTree.
+type PipelineTree struct {
+	PipelineID string
+	Workflows  []WorkflowTree
+}
+
+// A WorkflowTree is a workflow of a PipelineTree and its jobs. If Err is set,
+// the jobs could not be listed.
+type WorkflowTree struct {
+	Workflow Workflow
+	Jobs     []Job
+	Err      error
+}
+
+// A Job is a single unit of work within a Workflow.
+type Job struct {
+	ID                string     `json:"id"`
+	Name              string     `json:"name"`
+	JobNumber         *int       `json:"job_number"`
+	Type              string     `json:"type"`
+	Status            string     `json:"status"`
+	ProjectSlug       string     `json:"project_slug"`
+	ApprovalRequestID string     `json:"approval_request_id"`
+	Dependencies      []string   `json:"dependencies"`
+	StartedAt         *time.Time `json:"started_at"`
+	StoppedAt         *time.Time `json:"stopped_at"`
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+type listWorkflowsParams struct {
+	PipelineID *string
+	PageToken  *string
+}
+
+type listWorkflowsResponse struct {
+	Items         []Workflow
+	NextPageToken *string `json:"next_page_token"`
+}
+
+type listJobsParams struct {
+	WorkflowID *string
+	PageToken  *string
+}
+
+type listJobsResponse struct {
+	Items         []Job
+	NextPageToken *string `json:"next_page_token"`
+}
+
+// GetWorkflow returns the workflow with the given ID. It returns a
+// NotFoundError if there is no such workflow.
+func (c *ContextRestClient) GetWorkflow(workflowID string, opts ...CallOption) (*Workflow, error) {
+	req, err := c.newGetWorkflowRequest(workflowID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find workflow with ID '%s'", workflowID)}
+	}
+	var dest Workflow
+	if _, _, err := c.send(ctx, req, notFound, &dest); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newGetWorkflowRequest(workflowID string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("workflow/%s", workflowID))
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("GET", queryURL.String(), nil)
+}
+
+// ListJobsByWorkflow returns all of the jobs of a workflow. Note that
+// pagination is not currently supported - we get all pages of jobs and return
+// them all.
+func (c *ContextRestClient) ListJobsByWorkflow(workflowID string, opts ...CallOption) (*[]Job, error) {
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	jobs, err := c.listAllJobs(ctx,
+		&listJobsParams{
+			WorkflowID: &workflowID,
+		},
+	)
+	return &jobs, err
+}
+
+// GetJobByName returns the first job of the workflow with exactly the given
+// name. It returns a NotFoundError if the workflow has no such job.
+func (c *ContextRestClient) GetJobByName(workflowID, jobName string, opts ...CallOption) (*Job, error) {
+	jobs, err := c.ListJobsByWorkflow(workflowID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	for i, job := range *jobs {
+		if job.Name == jobName {
+			return &(*jobs)[i], nil
+		}
+	}
+	return nil, &NotFoundError{Message: fmt.Sprintf("Cannot find job named '%s'", jobName)}
+}
+
+// ApproveJob approves the pending approval job with the given approval request
+// ID, letting the rest of the workflow run. It returns a NotFoundError if there
+// is no such workflow or approval request.
+func (c *ContextRestClient) ApproveJob(workflowID, approvalRequestID string, opts ...CallOption) error {
+	req, err := c.newApproveJobRequest(workflowID, approvalRequestID)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newCallContext(opts)
+	defer cancel()
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find approval request '%s' in workflow '%s'", approvalRequestID, workflowID)}
+	}
+	_, _, err = c.send(ctx, req, notFound, nil)
+	return err
+}
+
+func (c *ContextRestClient) newApproveJobRequest(workflowID, approvalRequestID string) (*http.Request, error) {
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("workflow/%s/approve/%s", workflowID, approvalRequestID))
+	if err != nil {
+		return nil, err
+	}
+	return c.newHTTPRequest("POST", queryURL.String(), nil)
+}
+
+// GetPipelineTree returns the workflows of the pipeline with the given ID,
+// in the order the API lists them, each with its jobs. The jobs of the
+// workflows are listed concurrently, as configured by WithConcurrency;
+// WithFailFast is ignored, since a workflow whose jobs can't be listed
+// carries its own error in the tree. It returns a NotFoundError if there is
+// no such pipeline. Cancelling ctx stops the listing, and the partial tree is
+// returned with its error.
+func (c *ContextRestClient) GetPipelineTree(ctx context.Context, pipelineID string, opts ...BatchOption) (*PipelineTree, error) {
+	workflows, err := c.listAllWorkflows(ctx, &listWorkflowsParams{
+		PipelineID: &pipelineID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(workflows))
+	for _, workflow := range workflows {
+		ids = append(ids, workflow.ID)
+	}
+
+	var mu sync.Mutex
+	jobs := make(map[string][]Job, len(workflows))
+	errs := map[string]error{}
+	err = runBatch(ctx, ids, opts, func(ctx context.Context, workflowID string) error {
+		workflowJobs, err := c.listAllJobs(ctx, &listJobsParams{
+			WorkflowID: &workflowID,
+		})
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			errs[workflowID] = err
+		} else {
+			jobs[workflowID] = workflowJobs
+		}
+		return nil
+	})
+
+	tree := &PipelineTree{PipelineID: pipelineID, Workflows: make([]WorkflowTree, 0, len(workflows))}
+	for _, workflow := range workflows {
+		branch := WorkflowTree{Workflow: workflow, Jobs: jobs[workflow.ID], Err: errs[workflow.ID]}
+		if _, ok := jobs[workflow.ID]; !ok && branch.Err == nil {
+			branch.Err = err
+		}
+		tree.Workflows = append(tree.Workflows, branch)
+	}
+	return tree, err
+}
+
+func (c *ContextRestClient) listAllWorkflows(ctx context.Context, params *listWorkflowsParams) ([]Workflow, error) {
+	return paginate(ctx, params.PageToken, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]Workflow, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listWorkflows(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+}
+
+func (c *ContextRestClient) listWorkflows(ctx context.Context, params *listWorkflowsParams) (*listWorkflowsResponse, error) {
+	req, err := c.newListWorkflowsRequest(params)
+	if err != nil {
+		return nil, err
+	}
+
+	notFound := func() error {
+		return &NotFoundError{Message: fmt.Sprintf("Cannot find pipeline with ID '%s'", *params.PipelineID)}
+	}
+	var dest listWorkflowsResponse
+	resp, _, err := c.send(ctx, req, notFound, &dest)
+	if err != nil {
+		return nil, err
+	}
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newListWorkflowsRequest(params *listWorkflowsParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("pipeline/%s/workflow", *params.PipelineID))
+	if err != nil {
+		return nil, err
+	}
+
+	urlParams := url.Values{}
+	if params.PageToken != nil {
+		urlParams.Add("page-token", *params.PageToken)
+	}
+	queryURL.RawQuery = urlParams.Encode()
+
+	return c.newListRequest(queryURL.String())
+}
+
+func (c *ContextRestClient) listAllJobs(ctx context.Context, params *listJobsParams) ([]Job, error) {
+	return paginate(ctx, params.PageToken, 0, reportPages(c.pageCallback, func(ctx context.Context, pageToken *string) ([]Job, *string, error) {
+		params.PageToken = pageToken
+		resp, err := c.listJobs(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Items, resp.NextPageToken, nil
+	}))
+}
+
+func (c *ContextRestClient) listJobs(ctx context.Context, params *listJobsParams) (*listJobsResponse, error) {
+	req, err := c.newListJobsRequest(params)
+	if err != nil {
+		return nil, err
+	}
+
+	var dest listJobsResponse
+	resp, _, err := c.send(ctx, req, nil, &dest)
+	if err != nil {
+		return nil, err
+	}
+	if dest.NextPageToken, err = c.nextPageToken(resp, dest.NextPageToken); err != nil {
+		return nil, err
+	}
+	return &dest, nil
+}
+
+func (c *ContextRestClient) newListJobsRequest(params *listJobsParams) (*http.Request, error) {
+	if link, ok := c.pageLink(params.PageToken); ok {
+		return c.newListRequest(link)
+	}
+	var err error
+	queryURL, err := url.Parse(c.server)
+	if err != nil {
+		return nil, err
+	}
+	queryURL, err = queryURL.Parse(fmt.Sprintf("workflow/%s/job", *params.WorkflowID))
+	if err != nil {
+		return nil, err
+	}
+
+	urlParams := url.Values{}
+	if params.PageToken != nil {
+		urlParams.Add("page-token", *params.PageToken)
+	}
+	queryURL.RawQuery = urlParams.Encode()
+
+	return c.newListRequest(queryURL.String())
+}
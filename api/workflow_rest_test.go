@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	// we can't dot-import ginkgo because api.Context is a thing.
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = ginkgo.Describe("Workflow REST client", func() {
+	ginkgo.Describe("GetJobByName", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.URL.Path).To(Equal("/api/v2/workflow/workflow-id/job"))
+				switch req.URL.Query().Get("page-token") {
+				case "":
+					_, _ = rw.Write([]byte(`{"items": [{"id": "1", "name": "build"}, {"id": "2", "name": "Test"}], "next_page_token": "next"}`))
+				case "next":
+					_, _ = rw.Write([]byte(`{"items": [{"id": "3", "name": "test", "job_number": 12}, {"id": "4", "name": "test"}], "next_page_token": null}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("returns the first job with a matching name", func() {
+			client := newTestRestClient(server)
+			job, err := client.GetJobByName("workflow-id", "test")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(job.ID).To(Equal("3"))
+			Expect(*job.JobNumber).To(Equal(12))
+		})
+
+		ginkgo.It("returns a NotFoundError when no job matches", func() {
+			client := newTestRestClient(server)
+			_, err := client.GetJobByName("workflow-id", "deploy")
+			Expect(err).To(MatchError("Cannot find job named 'deploy'"))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("ApproveJob", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				Expect(req.Method).To(Equal("POST"))
+				if req.URL.Path != "/api/v2/workflow/workflow-id/approve/request-id" {
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Not found."}`))
+					return
+				}
+				rw.WriteHeader(http.StatusAccepted)
+				_, _ = rw.Write([]byte(`{"message": "Accepted."}`))
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("approves the job", func() {
+			client := newTestRestClient(server)
+			Expect(client.ApproveJob("workflow-id", "request-id")).To(Succeed())
+		})
+
+		ginkgo.It("returns a NotFoundError for an unknown approval request", func() {
+			client := newTestRestClient(server)
+			err := client.ApproveJob("workflow-id", "other-id")
+			Expect(err).To(MatchError("Cannot find approval request 'other-id' in workflow 'workflow-id'"))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("GetPipelineTree", func() {
+		var (
+			server *httptest.Server
+			client *ContextRestClient
+		)
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				switch req.URL.Path {
+				case "/api/v2/pipeline/pipeline-id/workflow":
+					_, _ = rw.Write([]byte(`{"items": [{"id": "build-id", "name": "build"}, {"id": "deploy-id", "name": "deploy"}]}`))
+				case "/api/v2/workflow/build-id/job":
+					_, _ = rw.Write([]byte(`{"items": [{"id": "1", "name": "compile"}, {"id": "2", "name": "test"}]}`))
+				case "/api/v2/workflow/deploy-id/job":
+					if req.URL.Query().Get("page-token") == "" {
+						_, _ = rw.Write([]byte(`{"items": [{"id": "3", "name": "hold"}], "next_page_token": "next"}`))
+						return
+					}
+					_, _ = rw.Write([]byte(`{"items": [{"id": "4", "name": "release"}]}`))
+				case "/api/v2/pipeline/broken-id/workflow":
+					_, _ = rw.Write([]byte(`{"items": [{"id": "build-id", "name": "build"}, {"id": "broken-id", "name": "broken"}]}`))
+				default:
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Not found."}`))
+				}
+			}))
+			client = newTestRestClient(server)
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		jobNames := func(jobs []Job) []string {
+			var names []string
+			for _, job := range jobs {
+				names = append(names, job.Name)
+			}
+			return names
+		}
+
+		ginkgo.It("assembles the workflows with their jobs", func() {
+			tree, err := client.GetPipelineTree(context.Background(), "pipeline-id", WithConcurrency(1))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tree.PipelineID).To(Equal("pipeline-id"))
+			Expect(tree.Workflows).To(HaveLen(2))
+			Expect(tree.Workflows[0].Workflow.Name).To(Equal("build"))
+			Expect(jobNames(tree.Workflows[0].Jobs)).To(Equal([]string{"compile", "test"}))
+			Expect(tree.Workflows[1].Workflow.Name).To(Equal("deploy"))
+			Expect(jobNames(tree.Workflows[1].Jobs)).To(Equal([]string{"hold", "release"}))
+			for _, workflow := range tree.Workflows {
+				Expect(workflow.Err).ToNot(HaveOccurred())
+			}
+		})
+
+		ginkgo.It("keeps the workflows whose jobs could be listed", func() {
+			tree, err := client.GetPipelineTree(context.Background(), "broken-id")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tree.Workflows).To(HaveLen(2))
+			Expect(jobNames(tree.Workflows[0].Jobs)).To(Equal([]string{"compile", "test"}))
+			Expect(tree.Workflows[0].Err).ToNot(HaveOccurred())
+			Expect(tree.Workflows[1].Jobs).To(BeEmpty())
+			Expect(tree.Workflows[1].Err).To(MatchError("Not found."))
+		})
+
+		ginkgo.It("reports a missing pipeline", func() {
+			_, err := client.GetPipelineTree(context.Background(), "missing-id")
+			Expect(err).To(MatchError("Cannot find pipeline with ID 'missing-id'"))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+
+	ginkgo.Describe("GetWorkflow", func() {
+		var server *httptest.Server
+
+		ginkgo.BeforeEach(func() {
+			server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				defer ginkgo.GinkgoRecover()
+				switch req.URL.Path {
+				case "/api/v2/workflow/original":
+					_, _ = rw.Write([]byte(`{"id": "original", "name": "build", "status": "failed", "started_by": "user-id", "created_at": "2021-01-01T00:00:00Z", "max_auto_reruns": 2}`))
+				case "/api/v2/workflow/rerun":
+					_, _ = rw.Write([]byte(`{"id": "rerun", "name": "build", "status": "success", "started_by": "user-id", "tag": "setup", "created_at": "2021-01-01T00:10:00Z", "stopped_at": "2021-01-01T00:20:00Z", "auto_rerun_number": 1, "max_auto_reruns": 2}`))
+				default:
+					rw.WriteHeader(http.StatusNotFound)
+					_, _ = rw.Write([]byte(`{"message": "Workflow not found"}`))
+				}
+			}))
+		})
+
+		ginkgo.AfterEach(func() {
+			server.Close()
+		})
+
+		ginkgo.It("maps the rerun fields of a rerun workflow", func() {
+			client := newTestRestClient(server)
+			workflow, err := client.GetWorkflow("rerun")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(workflow.StartedBy).To(Equal("user-id"))
+			Expect(workflow.Tag).To(Equal("setup"))
+			Expect(*workflow.AutoRerunNumber).To(Equal(1))
+			Expect(*workflow.MaxAutoReruns).To(Equal(2))
+			Expect(workflow.StoppedAt).ToNot(BeNil())
+			Expect(workflow.IsRerun()).To(BeTrue())
+		})
+
+		ginkgo.It("distinguishes the original run", func() {
+			client := newTestRestClient(server)
+			workflow, err := client.GetWorkflow("original")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(workflow.AutoRerunNumber).To(BeNil())
+			Expect(workflow.IsRerun()).To(BeFalse())
+		})
+
+		ginkgo.It("returns a NotFoundError for an unknown workflow", func() {
+			client := newTestRestClient(server)
+			_, err := client.GetWorkflow("missing")
+			Expect(err).To(MatchError("Cannot find workflow with ID 'missing'"))
+			Expect(IsNotFoundError(err)).To(BeTrue())
+		})
+	})
+})
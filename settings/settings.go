@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"github.com/CircleCI-Public/circleci-cli/data"
+	"golang.org/x/net/http/httpproxy"
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -201,6 +203,7 @@ func ensureSettingsFileExists(path string) error {
 func (cfg *Config) WithHTTPClient() error {
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: cfg.TLSInsecure,
+		MinVersion:         tls.VersionTLS12,
 	}
 
 	if cfg.TLSCert != "" {
@@ -222,9 +225,15 @@ func (cfg *Config) WithHTTPClient() error {
 		tlsConfig.RootCAs = pool
 	}
 
+	// Send requests through the proxy configured by the HTTP_PROXY,
+	// HTTPS_PROXY and NO_PROXY environment variables, as read now.
+	proxy := httpproxy.FromEnvironment().ProxyFunc()
 	cfg.HTTPClient = &http.Client{
 		Timeout: 30 * time.Second,
 		Transport: &http.Transport{
+			Proxy: func(req *http.Request) (*url.URL, error) {
+				return proxy(req.URL)
+			},
 			ExpectContinueTimeout: 1 * time.Second,
 			IdleConnTimeout:       90 * time.Second,
 			MaxIdleConns:          10,